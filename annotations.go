@@ -0,0 +1,216 @@
+// Package fountain is a Golang package supporting Fountain screenplay markup.
+//
+// annotations.go pulls the notes ([[ ... ]]) and boneyard (/* ... */)
+// out of the text of an element and puts them back when rendering.
+package fountain
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// placeholder is the first of the private use runes marking where the
+// annotations shown go while the emphasis is rendered.
+const placeholder = '\uE000'
+
+// Annotation is a note ([[ ... ]]) or boneyard (/* ... */) found in the
+// text of an element. Content holds it as written, with its markers,
+// and Offset is where it was removed from the element's Content.
+type Annotation struct {
+	Type    int    `json:"type" yaml:"type"`
+	Content string `json:"content" yaml:"content"`
+	Offset  int    `json:"offset" yaml:"offset"`
+}
+
+// nextAnnotation returns the position of the first note or boneyard
+// opened in s and the marker closing it, -1 if there is none.
+func nextAnnotation(s string) (int, string) {
+	note, boneyard := strings.Index(s, "[["), strings.Index(s, "/*")
+	switch {
+	case note >= 0 && (boneyard < 0 || note < boneyard):
+		return note, "]]"
+	case boneyard >= 0:
+		return boneyard, "*/"
+	}
+	return -1, ""
+}
+
+// visibleText returns the line without its notes and boneyard. The
+// closing marker of an annotation left open by the previous lines is
+// passed in open ("]]" or "*/"), the marker still open at the end of
+// the line is returned.
+func visibleText(line string, open string) (string, string) {
+	visible := []string{}
+	for {
+		if open != "" {
+			end := strings.Index(line, open)
+			if end < 0 {
+				return strings.Join(visible, ""), open
+			}
+			line, open = line[end+len(open):], ""
+		}
+		start, closing := nextAnnotation(line)
+		if start < 0 {
+			visible = append(visible, line)
+			return strings.Join(visible, ""), ""
+		}
+		visible = append(visible, line[0:start])
+		line, open = line[start+2:], closing
+	}
+}
+
+// annotationType returns the element type of a line holding only notes
+// or boneyard.
+func annotationType(line string) int {
+	if strings.HasPrefix(strings.TrimSpace(line), "/*") {
+		return BoneyardType
+	}
+	return NoteType
+}
+
+// isSpaceBefore returns true if the text before an annotation ends
+// with a space (not a line end) so the space can go with it.
+func isSpaceBefore(before string) bool {
+	return strings.HasSuffix(before, " ") || strings.HasSuffix(before, "\t")
+}
+
+// isWordAfter returns true if the text after an annotation starts a
+// word, i.e. not white space or punctuation.
+func isWordAfter(after string) bool {
+	r, _ := utf8.DecodeRuneInString(after)
+	return after != "" && !unicode.IsSpace(r) && !unicode.IsPunct(r)
+}
+
+// parseAnnotations moves the notes and boneyard found in the element's
+// text into Annotations. Together with the annotation a space is removed
+// so "no [[rewrite this]] and" reads "no and". Unterminated notes and
+// boneyard are left in the text.
+func (element *Element) parseAnnotations() {
+	element.Annotations = nil
+	switch element.Type {
+	case TitlePageType, NoteType, BoneyardType, EmptyType, PageFeed:
+		return
+	}
+	rest := element.Content
+	content := []string{}
+	offset := 0
+	annotations := []*Annotation{}
+	for {
+		start, closing := nextAnnotation(rest)
+		if start < 0 {
+			break
+		}
+		end := strings.Index(rest[start+2:], closing)
+		if end < 0 {
+			break
+		}
+		end += start + 2 + len(closing)
+		before, after := rest[0:start], rest[end:]
+		switch {
+		case isSpaceBefore(before) && !isWordAfter(after):
+			before = before[0 : len(before)-1]
+		case !isSpaceBefore(before) && (strings.HasPrefix(after, " ") || strings.HasPrefix(after, "\t")):
+			after = after[1:]
+		}
+		content = append(content, before)
+		offset += len(before)
+		annotation := &Annotation{Type: NoteType, Content: rest[start:end], Offset: offset}
+		if closing == "*/" {
+			annotation.Type = BoneyardType
+		}
+		annotations = append(annotations, annotation)
+		rest = after
+	}
+	if len(annotations) > 0 {
+		element.Content = strings.Join(append(content, rest), "")
+		element.Annotations = annotations
+	}
+}
+
+// shownAnnotations returns the annotations shown with the options
+func (element *Element) shownAnnotations(opt *Options) []*Annotation {
+	shown := []*Annotation{}
+	for _, annotation := range element.Annotations {
+		if (annotation.Type == NoteType && opt.ShowNotes) || (annotation.Type == BoneyardType && opt.ShowBoneyard) {
+			shown = append(shown, annotation)
+		}
+	}
+	return shown
+}
+
+// withPlaceholders returns the content with a placeholder where each
+// annotation goes, spaced from the words around it.
+func withPlaceholders(content string, annotations []*Annotation) string {
+	out := []string{}
+	prev := 0
+	for i, annotation := range annotations {
+		offset := min(max(annotation.Offset, prev), len(content))
+		before, after := content[prev:offset], content[offset:]
+		out = append(out, before)
+		if offset > 0 && !unicode.IsSpace(rune(content[offset-1])) {
+			out = append(out, " ")
+		}
+		out = append(out, string(placeholder+rune(i)))
+		if isWordAfter(after) {
+			out = append(out, " ")
+		}
+		prev = offset
+	}
+	return strings.Join(append(out, content[prev:]), "")
+}
+
+// replacePlaceholders puts the rendered annotations in place of their
+// placeholders
+func replacePlaceholders(s string, annotations []*Annotation, render func(*Annotation) string) string {
+	pairs := []string{}
+	for i, annotation := range annotations {
+		pairs = append(pairs, string(placeholder+rune(i)), render(annotation))
+	}
+	return strings.NewReplacer(pairs...).Replace(s)
+}
+
+// textFor returns the element's text as Fountain markup with the notes
+// and boneyard shown by opt put back. The transform function (e.g.
+// strings.ToUpper) is not applied to the notes.
+func (element *Element) textFor(opt *Options, transform func(string) string) string {
+	if transform == nil {
+		transform = func(s string) string { return s }
+	}
+	shown := element.shownAnnotations(opt)
+	if len(shown) == 0 {
+		return transform(element.text())
+	}
+	s := withPlaceholders(element.Content, shown)
+	if element.Spans != nil {
+		s = spansToFountain(ParseEmphasis(s))
+	}
+	return replacePlaceholders(transform(s), shown, func(annotation *Annotation) string {
+		return annotation.Content
+	})
+}
+
+// htmlFor returns the element's text as HTML with the notes and boneyard
+// shown by opt put back. The transform function (e.g. strings.ToUpper)
+// is not applied to the notes.
+func (element *Element) htmlFor(opt *Options, transform func(string) string) string {
+	shown := element.shownAnnotations(opt)
+	if len(shown) == 0 {
+		return element.html(transform)
+	}
+	s := withPlaceholders(element.Content, shown)
+	switch {
+	case element.Spans != nil:
+		s = spansToHTML(ParseEmphasis(s), transform)
+	case transform != nil:
+		s = transform(s)
+	}
+	return replacePlaceholders(s, shown, func(annotation *Annotation) string {
+		class := "note"
+		if annotation.Type == BoneyardType {
+			class = "boneyard"
+		}
+		return fmt.Sprintf("<span class=%q>%s</span>", class, annotation.Content)
+	})
+}
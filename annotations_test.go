@@ -0,0 +1,139 @@
+package fountain
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAnnotations(t *testing.T) {
+	src := `INT. HOUSE - DAY [[check the set]] #4#
+
+BOB
+I said no [[rewrite this]] and left.
+
+ALICE
+Really /* cut this
+
+and this too */ now?
+
+/* A whole
+
+cut scene */
+
+Bob *enters*. [[He limps.]]
+
+[[A note with
+  
+two spaces]]
+
+[[An open note
+
+Bob sits.
+`
+	doc, err := Parse([]byte(src))
+	assertOK(t, err, "Parse(src)")
+	if got := doc.RenderSource(nil); got != src {
+		t.Errorf("expected the source back, got %q", got)
+	}
+	elements := []*Element{}
+	for _, element := range doc.Elements {
+		if element.Type != EmptyType {
+			elements = append(elements, element)
+		}
+	}
+	expected := []struct {
+		Type        int
+		Content     string
+		Annotations []*Annotation
+	}{
+		{SceneHeadingType, "INT. HOUSE - DAY", []*Annotation{{NoteType, "[[check the set]]", 16}}},
+		{CharacterType, "BOB", nil},
+		{DialogueType, "I said no and left.", []*Annotation{{NoteType, "[[rewrite this]]", 9}}},
+		{CharacterType, "ALICE", nil},
+		{DialogueType, "Really now?", []*Annotation{{BoneyardType, "/* cut this\n\nand this too */", 6}}},
+		{BoneyardType, "/* A whole\n\ncut scene */", nil},
+		{ActionType, "Bob *enters*.", []*Annotation{{NoteType, "[[He limps.]]", 13}}},
+		{NoteType, "[[A note with\n  \ntwo spaces]]", nil},
+		{NoteType, "[[An open note", nil},
+		{ActionType, "Bob sits.", nil},
+	}
+	if len(elements) != len(expected) {
+		for _, element := range elements {
+			t.Logf("%s %q", element.TypeName(), element.Content)
+		}
+		t.Fatalf("expected %d elements, got %d", len(expected), len(elements))
+	}
+	for i, e := range expected {
+		element := elements[i]
+		if element.Type != e.Type || element.Content != e.Content || len(element.Annotations) != len(e.Annotations) {
+			t.Errorf("element %d: expected %s %q with %d annotations, got %s %q with %d", i, typeName(e.Type), e.Content, len(e.Annotations), element.TypeName(), element.Content, len(element.Annotations))
+			continue
+		}
+		for j, annotation := range e.Annotations {
+			if got := element.Annotations[j]; *got != *annotation {
+				t.Errorf("element %d: expected annotation %+v, got %+v", i, annotation, got)
+			}
+		}
+	}
+	if elements[0].SceneNumber != "4" {
+		t.Errorf("expected scene number 4, got %q", elements[0].SceneNumber)
+	}
+	if elements[6].Spans == nil {
+		t.Errorf("expected the emphasis parsed without the note")
+	}
+
+	// Notes and boneyard are hidden by default
+	s := doc.String()
+	for _, hidden := range []string{"[[", "/*", "cut scene"} {
+		if strings.Contains(s, hidden) {
+			t.Errorf("expected no %q in\n%s", hidden, s)
+		}
+	}
+	for _, expected := range []string{"INT. HOUSE - DAY #4#\n", "I said no and left.\n", "Really now?\n", "Bob *enters*.\n"} {
+		if !strings.Contains(s, expected) {
+			t.Errorf("expected %q in\n%s", expected, s)
+		}
+	}
+	html := doc.ToHTML()
+	if strings.Contains(html, "[[") || strings.Contains(html, "/*") {
+		t.Errorf("expected no notes or boneyard in\n%s", html)
+	}
+
+	// and put back where they were found when shown
+	opt := DefaultOptions()
+	opt.ShowNotes = true
+	s = doc.RenderString(opt)
+	for _, expected := range []string{"INT. HOUSE - DAY [[check the set]] #4#\n", "I said no [[rewrite this]] and left.\n", "Bob *enters*. [[He limps.]]\n", "\n[[A note with\n"} {
+		if !strings.Contains(s, expected) {
+			t.Errorf("expected %q in\n%s", expected, s)
+		}
+	}
+	if strings.Contains(s, "/*") {
+		t.Errorf("expected no boneyard in\n%s", s)
+	}
+	opt.ShowBoneyard = true
+	s = doc.RenderString(opt)
+	for _, expected := range []string{"        Really /* cut this\n\n        and this too */ now?\n", "\n/* A whole\n\ncut scene */\n"} {
+		if !strings.Contains(s, expected) {
+			t.Errorf("expected %q in\n%s", expected, s)
+		}
+	}
+	html = doc.RenderHTML(opt)
+	for _, expected := range []string{
+		`INT. HOUSE - DAY <span class="note">[[check the set]]</span><span class="scene-number-right">4</span>`,
+		`<div class="dialogue">I said no <span class="note">[[rewrite this]]</span> and left.</div>`,
+		`<div class="action">Bob <em>enters</em>. <span class="note">[[He limps.]]</span></div>`,
+		`<div class="boneyard">/* A whole`,
+	} {
+		if !strings.Contains(html, expected) {
+			t.Errorf("expected %q in\n%s", expected, html)
+		}
+	}
+
+	// Inline notes become script notes in Final Draft
+	fdx, err := doc.ToFDX()
+	assertOK(t, err, "doc.ToFDX()")
+	if !strings.Contains(string(fdx), "rewrite this") || strings.Contains(string(fdx), "[[rewrite") {
+		t.Errorf("expected the inline note as a script note in\n%s", fdx)
+	}
+}
@@ -0,0 +1,138 @@
+// Package fountain is a Golang package supporting Fountain screenplay markup.
+//
+// characters.go gathers per character statistics (e.g. for casting).
+package fountain
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// reExtension matches a character extension, e.g. (V.O.), (O.S.), (CONT'D)
+var reExtension = regexp.MustCompile(`\(([^)]+)\)`)
+
+// CharacterStats holds the statistics for a character's dialogue.
+// Scenes are identified by their scene number or, if not numbered, by
+// their position in the script counting from one.
+type CharacterStats struct {
+	Name           string   `json:"name" yaml:"name"`
+	DialogueBlocks int      `json:"dialogue_blocks" yaml:"dialogue_blocks"`
+	Words          int      `json:"words" yaml:"words"`
+	Scenes         []string `json:"scenes,omitempty" yaml:"scenes,omitempty"`
+	FirstScene     string   `json:"first_scene,omitempty" yaml:"first_scene,omitempty"`
+	LastScene      string   `json:"last_scene,omitempty" yaml:"last_scene,omitempty"`
+	FirstLine      int      `json:"first_line,omitempty" yaml:"first_line,omitempty"`
+	LastLine       int      `json:"last_line,omitempty" yaml:"last_line,omitempty"`
+	Extensions     []string `json:"extensions,omitempty" yaml:"extensions,omitempty"`
+}
+
+// plainText returns the element's content without any emphasis markup
+func plainText(element *Element) string {
+	if element.Spans != nil {
+		return spansText(element.Spans)
+	}
+	return element.Content
+}
+
+// CharacterExtensions returns the extensions of a character cue without
+// the parentheses, e.g. "BOB (V.O.) (CONT'D)" returns "V.O." and "CONT'D".
+func CharacterExtensions(element *Element) []string {
+	extensions := []string{}
+	if element.Type != CharacterType {
+		return extensions
+	}
+	for _, m := range reExtension.FindAllStringSubmatch(element.Content, -1) {
+		extensions = append(extensions, strings.ToUpper(strings.TrimSpace(m[1])))
+	}
+	return extensions
+}
+
+// sceneIDs returns the identifier of the scene each element belongs to,
+// an empty string for elements before the first scene.
+func (doc *Fountain) sceneIDs() []string {
+	ids := make([]string, len(doc.Elements))
+	id, count := "", 0
+	for i, element := range doc.Elements {
+		if isScene(element) {
+			count++
+			id = element.SceneNumber
+			if id == "" {
+				id = fmt.Sprintf("%d", count)
+			}
+		}
+		ids[i] = id
+	}
+	return ids
+}
+
+// CharacterStats returns the statistics for each character that speaks
+// in the order they first appear. A dialogue block is a character cue
+// with the dialogue that follows, words are counted in the dialogue
+// only (i.e. not in parentheticals).
+func (doc *Fountain) CharacterStats() []*CharacterStats {
+	stats := []*CharacterStats{}
+	byName := map[string]*CharacterStats{}
+	var current *CharacterStats
+	add := func(element *Element, scene string) {
+		switch element.Type {
+		case CharacterType:
+			name := CharacterName(element)
+			if name == "" {
+				current = nil
+				return
+			}
+			current = byName[name]
+			if current == nil {
+				current = &CharacterStats{Name: name, FirstScene: scene}
+				if element.Start != nil {
+					current.FirstLine = element.Start.Line
+				}
+				byName[name] = current
+				stats = append(stats, current)
+			}
+			current.DialogueBlocks++
+			current.LastScene = scene
+			if element.Start != nil {
+				current.LastLine = element.Start.Line
+			}
+			if scene != "" && (len(current.Scenes) == 0 || current.Scenes[len(current.Scenes)-1] != scene) {
+				current.Scenes = append(current.Scenes, scene)
+			}
+			for _, extension := range CharacterExtensions(element) {
+				if !containsString(current.Extensions, extension) {
+					current.Extensions = append(current.Extensions, extension)
+				}
+			}
+		case DialogueType:
+			if current != nil {
+				current.Words += len(strings.Fields(plainText(element)))
+			}
+		case ParentheticalType:
+		default:
+			current = nil
+		}
+	}
+	for i, scene := range doc.sceneIDs() {
+		element := doc.Elements[i]
+		if element.Type == DualDialogueType {
+			for _, elem := range element.Elements {
+				add(elem, scene)
+			}
+			current = nil
+		} else {
+			add(element, scene)
+		}
+	}
+	return stats
+}
+
+// containsString returns true if target is in the list of strings
+func containsString(list []string, target string) bool {
+	for _, s := range list {
+		if s == target {
+			return true
+		}
+	}
+	return false
+}
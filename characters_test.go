@@ -0,0 +1,51 @@
+package fountain
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCharacterStats(t *testing.T) {
+	src := []byte(`INT. KITCHEN - DAY
+
+BOB (V.O.)
+(quietly)
+The pot is hot.
+
+ALICE
+So it is.
+
+EXT. GARDEN - NIGHT #5#
+
+BOB (CONT'D)
+Come outside.
+
+ALICE
+No.
+
+BOB
+Please?
+`)
+	doc, err := Parse(src)
+	assertOK(t, err, "Parse(src)")
+	stats := doc.CharacterStats()
+	if len(stats) != 2 {
+		t.Fatalf("expected 2 characters, got %d", len(stats))
+	}
+	bob, alice := stats[0], stats[1]
+	if bob.Name != "BOB" || bob.DialogueBlocks != 3 || bob.Words != 7 {
+		t.Errorf("unexpected stats for BOB %+v", bob)
+	}
+	if strings.Join(bob.Scenes, ",") != "1,5" || bob.FirstScene != "1" || bob.LastScene != "5" {
+		t.Errorf("unexpected scenes for BOB %+v", bob)
+	}
+	if bob.FirstLine != 3 || bob.LastLine != 18 {
+		t.Errorf("unexpected first and last lines for BOB %+v", bob)
+	}
+	if strings.Join(bob.Extensions, ",") != "V.O.,CONT'D" {
+		t.Errorf("unexpected extensions for BOB %+v", bob)
+	}
+	if alice.Name != "ALICE" || alice.DialogueBlocks != 2 || alice.Words != 4 || len(alice.Extensions) != 0 {
+		t.Errorf("unexpected stats for ALICE %+v", alice)
+	}
+}
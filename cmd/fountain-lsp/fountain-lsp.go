@@ -0,0 +1,159 @@
+//
+// fountain-lsp is a Language Server Protocol server for Fountain files.
+//
+//
+// fountain is a package encoding/decoding fountain formatted screenplays.
+//
+// @author R. S. Doiel, <rsdoiel@gmail.com>
+//
+// BSD 2-Clause License
+//
+// Copyright (c) 2019, R. S. Doiel
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+//
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path"
+
+	// My packages
+	"github.com/rsdoiel/fountain"
+)
+
+var (
+	helpText = `%{app_name}(1) | version {version} {release_hash}
+% R. S. Doiel
+% {release_date}
+
+# NAME
+
+{app_name}
+
+# SYNOPSIS
+
+{app_name} [OPTIONS]
+
+# DESCRIPTION
+
+{app_name} is a Language Server Protocol (LSP) server for fountain documents. It speaks JSON-RPC over standard input and output and is started by your editor (e.g. VS Code, Neovim, Helix). It provides
+
+- a document outline of the sections and scene headings
+- completion of the character names used in the document
+- diagnostics, the same checks as fountainlint
+- folding of boneyard (/\* ... \*/) and notes (\[\[ ... \]\])
+- formatting, the same pretty printing as fountainfmt keeping sections, synopses and notes
+
+# OPTIONS
+
+-help
+: display help
+
+-license
+: display license
+
+-version
+: display version
+
+
+# EXAMPLES
+
+Configure Helix to use {app_name} for fountain files in *languages.toml*.
+
+~~~
+    [language-server.fountain-lsp]
+    command = "{app_name}"
+
+    [[language]]
+    name = "fountain"
+    scope = "source.fountain"
+    file-types = ["fountain"]
+    language-servers = ["fountain-lsp"]
+~~~
+
+Configure Neovim to start {app_name} for fountain files.
+
+~~~
+    vim.api.nvim_create_autocmd("FileType", {
+      pattern = "fountain",
+      callback = function()
+        vim.lsp.start({ name = "fountain-lsp", cmd = { "{app_name}" } })
+      end,
+    })
+~~~
+
+`
+
+	// Standard Options
+	showHelp    bool
+	showLicense bool
+	showVersion bool
+)
+
+func main() {
+	appName := path.Base(os.Args[0])
+	// NOTE: These are set when version.go is generated
+	version := fountain.Version
+	releaseDate := fountain.ReleaseDate
+	releaseHash := fountain.ReleaseHash
+	fmtHelp := fountain.FmtHelp
+
+	// Standard Options
+	flag.BoolVar(&showHelp, "help", false, "display help")
+	flag.BoolVar(&showLicense, "license", false, "display license")
+	flag.BoolVar(&showVersion, "version", false, "display version")
+
+	// Parse environment and options
+	flag.Parse()
+
+	out := os.Stdout
+	eout := os.Stderr
+
+	// Process options
+	if showHelp {
+		fmt.Fprintf(out, "%s\n", fmtHelp(helpText, appName, version, releaseDate, releaseHash))
+		os.Exit(0)
+	}
+	if showLicense {
+		fmt.Fprintf(out, "%s\n", fountain.LicenseText)
+		os.Exit(0)
+	}
+	if showVersion {
+		fmt.Fprintf(out, "%s %s %s\n", appName, version, releaseHash)
+		os.Exit(0)
+	}
+
+	// Serve requests until the editor sends exit
+	s := newServer(os.Stdin, out)
+	if err := s.serve(); err != nil {
+		fmt.Fprintf(eout, "%s\n", err)
+		os.Exit(1)
+	}
+	if !s.shutdown {
+		// NOTE: LSP asks for exit code 1 when exit comes without shutdown
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,419 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	// My packages
+	"github.com/rsdoiel/fountain"
+)
+
+// JSON-RPC error codes used by the server
+const (
+	parseError     = -32700
+	invalidParams  = -32602
+	methodNotFound = -32601
+)
+
+// LSP constants used by the server
+const (
+	textDocumentSyncFull = 1
+
+	diagnosticError   = 1
+	diagnosticWarning = 2
+
+	symbolNamespace = 3
+	symbolClass     = 5
+
+	completionReference = 18
+)
+
+// message is a JSON-RPC request, notification or response
+type message struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method,omitempty"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+	Result  interface{}      `json:"result,omitempty"`
+	Error   *responseError   `json:"error,omitempty"`
+}
+
+// responseError is the error returned for a failed request
+type responseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// position is a zero based line and UTF-16 character offset
+type position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// textRange is the range between two positions
+type textRange struct {
+	Start position `json:"start"`
+	End   position `json:"end"`
+}
+
+type textDocumentItem struct {
+	URI     string `json:"uri"`
+	Text    string `json:"text"`
+	Version int    `json:"version"`
+}
+
+type documentParams struct {
+	TextDocument   textDocumentItem `json:"textDocument"`
+	ContentChanges []struct {
+		Text string `json:"text"`
+	} `json:"contentChanges,omitempty"`
+}
+
+type diagnostic struct {
+	Range    textRange `json:"range"`
+	Severity int       `json:"severity"`
+	Code     string    `json:"code"`
+	Source   string    `json:"source"`
+	Message  string    `json:"message"`
+}
+
+type documentSymbol struct {
+	Name           string            `json:"name"`
+	Detail         string            `json:"detail,omitempty"`
+	Kind           int               `json:"kind"`
+	Range          textRange         `json:"range"`
+	SelectionRange textRange         `json:"selectionRange"`
+	Children       []*documentSymbol `json:"children,omitempty"`
+}
+
+type completionItem struct {
+	Label  string `json:"label"`
+	Kind   int    `json:"kind"`
+	Detail string `json:"detail,omitempty"`
+}
+
+type foldingRange struct {
+	StartLine int    `json:"startLine"`
+	EndLine   int    `json:"endLine"`
+	Kind      string `json:"kind,omitempty"`
+}
+
+type textEdit struct {
+	Range   textRange `json:"range"`
+	NewText string    `json:"newText"`
+}
+
+// server is a Language Server Protocol server for Fountain documents
+// speaking JSON-RPC over a reader and writer (e.g. stdin and stdout).
+type server struct {
+	in   *bufio.Reader
+	out  io.Writer
+	docs map[string]string
+	// shutdown is set by the shutdown request, exit then ends serve
+	shutdown bool
+}
+
+// newServer returns a server reading requests from in and writing
+// responses and notifications to out
+func newServer(in io.Reader, out io.Writer) *server {
+	return &server{in: bufio.NewReader(in), out: out, docs: map[string]string{}}
+}
+
+// readMessage reads a message framed by a Content-Length header
+func (s *server) readMessage() (*message, error) {
+	header, err := textproto.NewReader(s.in).ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil {
+		return nil, fmt.Errorf("bad Content-Length %q", header.Get("Content-Length"))
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(s.in, body); err != nil {
+		return nil, err
+	}
+	msg := new(message)
+	if err := json.Unmarshal(body, msg); err != nil {
+		return &message{Error: &responseError{Code: parseError, Message: err.Error()}}, nil
+	}
+	return msg, nil
+}
+
+// writeMessage writes a message framed by a Content-Length header
+func (s *server) writeMessage(msg *message) error {
+	msg.JSONRPC = "2.0"
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(s.out, "Content-Length: %d\r\n\r\n%s", len(body), body)
+	return err
+}
+
+// notify sends a notification to the client
+func (s *server) notify(method string, params interface{}) error {
+	src, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	return s.writeMessage(&message{Method: method, Params: src})
+}
+
+// serve handles messages until the client sends exit or closes the
+// connection
+func (s *server) serve() error {
+	for {
+		msg, err := s.readMessage()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if msg.Error != nil {
+			if err := s.writeMessage(&message{ID: msg.ID, Error: msg.Error}); err != nil {
+				return err
+			}
+			continue
+		}
+		if msg.Method == "exit" {
+			return nil
+		}
+		result, rpcErr := s.handle(msg)
+		if msg.ID == nil {
+			// Notifications don't get a response
+			continue
+		}
+		response := &message{ID: msg.ID, Result: result, Error: rpcErr}
+		if result == nil && rpcErr == nil {
+			response.Result = json.RawMessage("null")
+		}
+		if err := s.writeMessage(response); err != nil {
+			return err
+		}
+	}
+}
+
+// handle dispatches a request or notification to its method
+func (s *server) handle(msg *message) (interface{}, *responseError) {
+	params := new(documentParams)
+	if len(msg.Params) > 0 {
+		if err := json.Unmarshal(msg.Params, params); err != nil {
+			return nil, &responseError{Code: invalidParams, Message: err.Error()}
+		}
+	}
+	uri := params.TextDocument.URI
+	switch msg.Method {
+	case "initialize":
+		return map[string]interface{}{
+			"capabilities": map[string]interface{}{
+				"textDocumentSync":           textDocumentSyncFull,
+				"documentSymbolProvider":     true,
+				"completionProvider":         map[string]interface{}{},
+				"foldingRangeProvider":       true,
+				"documentFormattingProvider": true,
+			},
+			"serverInfo": map[string]string{"name": "fountain-lsp", "version": fountain.Version},
+		}, nil
+	case "initialized", "$/cancelRequest", "$/setTrace":
+		return nil, nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+	case "textDocument/didOpen":
+		s.docs[uri] = params.TextDocument.Text
+		s.publishDiagnostics(uri)
+		return nil, nil
+	case "textDocument/didChange":
+		// Full document sync, the last change holds the whole text
+		if n := len(params.ContentChanges); n > 0 {
+			s.docs[uri] = params.ContentChanges[n-1].Text
+		}
+		s.publishDiagnostics(uri)
+		return nil, nil
+	case "textDocument/didClose":
+		delete(s.docs, uri)
+		s.notify("textDocument/publishDiagnostics", map[string]interface{}{"uri": uri, "diagnostics": []*diagnostic{}})
+		return nil, nil
+	case "textDocument/documentSymbol":
+		return documentSymbols(s.docs[uri]), nil
+	case "textDocument/completion":
+		return characterCompletions(s.docs[uri]), nil
+	case "textDocument/foldingRange":
+		return foldingRanges(s.docs[uri]), nil
+	case "textDocument/formatting":
+		return formatDocument(s.docs[uri]), nil
+	}
+	if msg.ID == nil {
+		return nil, nil
+	}
+	return nil, &responseError{Code: methodNotFound, Message: fmt.Sprintf("method %q not supported", msg.Method)}
+}
+
+// parse parses the text of a document, it never fails on partial
+// documents so errors are ignored
+func parse(text string) *fountain.Fountain {
+	doc, err := fountain.Parse([]byte(text))
+	if err != nil || doc == nil {
+		return new(fountain.Fountain)
+	}
+	return doc
+}
+
+// utf16Len returns the length of s in UTF-16 code units as used by
+// LSP positions
+func utf16Len(s string) int {
+	return len(utf16.Encode([]rune(s)))
+}
+
+// lineRange returns the range covering source lines first to last
+// (one based)
+func lineRange(lines []string, first int, last int) textRange {
+	end := 0
+	if last >= 1 && last <= len(lines) {
+		end = utf16Len(lines[last-1])
+	}
+	return textRange{Start: position{Line: first - 1}, End: position{Line: last - 1, Character: end}}
+}
+
+// publishDiagnostics sends the lint diagnostics for a document
+func (s *server) publishDiagnostics(uri string) {
+	text := s.docs[uri]
+	lines := strings.Split(text, "\n")
+	diagnostics := []*diagnostic{}
+	for _, d := range fountain.Lint(parse(text)) {
+		if d.Line < 1 || d.Line > len(lines) {
+			continue
+		}
+		line := lines[d.Line-1]
+		column := d.Column - 1
+		if column < 0 || column > len(line) {
+			column = 0
+		}
+		r := lineRange(lines, d.Line, d.Line)
+		r.Start.Character = utf16Len(line[0:column])
+		severity := diagnosticWarning
+		if d.Severity == fountain.SeverityError {
+			severity = diagnosticError
+		}
+		diagnostics = append(diagnostics, &diagnostic{
+			Range:    r,
+			Severity: severity,
+			Code:     d.Code,
+			Source:   "fountainlint",
+			Message:  d.Message,
+		})
+	}
+	s.notify("textDocument/publishDiagnostics", map[string]interface{}{"uri": uri, "diagnostics": diagnostics})
+}
+
+// documentSymbols returns the outline of a document, sections hold the
+// sections nested below them (by the number of #) and the scenes.
+func documentSymbols(text string) []*documentSymbol {
+	lines := strings.Split(text, "\n")
+	doc := parse(text)
+	// lastLine returns the line before the element ending a node
+	lastLine := func(node *fountain.OutlineNode) int {
+		if node.End < len(doc.Elements) && doc.Elements[node.End].Start != nil {
+			return doc.Elements[node.End].Start.Line - 1
+		}
+		return len(lines)
+	}
+	var symbolsFor func(nodes []*fountain.OutlineNode) []*documentSymbol
+	symbolsFor = func(nodes []*fountain.OutlineNode) []*documentSymbol {
+		symbols := []*documentSymbol{}
+		for _, node := range nodes {
+			symbol := &documentSymbol{Name: node.Text}
+			switch node.Type {
+			case fountain.SectionType:
+				symbol.Kind = symbolNamespace
+			case fountain.SceneHeadingType:
+				symbol.Kind = symbolClass
+				if element := doc.Elements[node.Start]; element.SceneNumber != "" {
+					symbol.Detail = "#" + element.SceneNumber
+				}
+			default:
+				// Synopses are not part of the outline
+				continue
+			}
+			symbol.Range = lineRange(lines, node.Line, lastLine(node))
+			symbol.SelectionRange = lineRange(lines, node.Line, node.Line)
+			symbol.Children = symbolsFor(node.Children)
+			symbols = append(symbols, symbol)
+		}
+		return symbols
+	}
+	return symbolsFor(doc.Outline().Nodes)
+}
+
+// characterCompletions returns the names of the characters who speak
+// in the document
+func characterCompletions(text string) []*completionItem {
+	items := []*completionItem{}
+	for _, c := range parse(text).CharacterStats() {
+		items = append(items, &completionItem{
+			Label:  c.Name,
+			Kind:   completionReference,
+			Detail: fmt.Sprintf("%d dialogue blocks", c.DialogueBlocks),
+		})
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Label < items[j].Label
+	})
+	return items
+}
+
+// foldingRanges returns the boneyard (/* ... */) and notes ([[ ... ]])
+// that span more than one line
+func foldingRanges(text string) []*foldingRange {
+	ranges := []*foldingRange{}
+	closer, start := "", 0
+	for i, line := range strings.Split(text, "\n") {
+		for j := 0; j+1 < len(line); j++ {
+			token := line[j : j+2]
+			switch {
+			case closer != "":
+				if token == closer {
+					if i > start {
+						ranges = append(ranges, &foldingRange{StartLine: start, EndLine: i, Kind: "comment"})
+					}
+					closer = ""
+					j++
+				}
+			case token == "/*":
+				closer, start = "*/", i
+				j++
+			case token == "[[":
+				closer, start = "]]", i
+				j++
+			}
+		}
+	}
+	return ranges
+}
+
+// formatDocument pretty prints the document the way fountainfmt does,
+// keeping the sections, synopses, notes and boneyard. It returns no edits if the
+// document is already formatted.
+func formatDocument(text string) []*textEdit {
+	opt := fountain.DefaultOptions()
+	opt.MaxWidth = 65
+	opt.ShowSection = true
+	opt.ShowSynopsis = true
+	opt.ShowNotes = true
+	opt.ShowBoneyard = true
+	formatted := parse(text).RenderString(opt) + "\n"
+	if formatted == text {
+		return []*textEdit{}
+	}
+	lines := strings.Split(text, "\n")
+	return []*textEdit{{Range: lineRange(lines, 1, len(lines)), NewText: formatted}}
+}
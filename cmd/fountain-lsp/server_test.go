@@ -0,0 +1,251 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// client is an in-process JSON-RPC client talking to a server over pipes
+type client struct {
+	t             *testing.T
+	w             io.WriteCloser
+	r             *bufio.Reader
+	id            int
+	notifications []*message
+	done          chan error
+}
+
+func newClient(t *testing.T) *client {
+	serverIn, clientOut := io.Pipe()
+	clientIn, serverOut := io.Pipe()
+	c := &client{t: t, w: clientOut, r: bufio.NewReader(clientIn), done: make(chan error, 1)}
+	go func() {
+		err := newServer(serverIn, serverOut).serve()
+		serverOut.Close()
+		c.done <- err
+	}()
+	return c
+}
+
+func (c *client) send(msg map[string]interface{}) {
+	msg["jsonrpc"] = "2.0"
+	body, err := json.Marshal(msg)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	if _, err := fmt.Fprintf(c.w, "Content-Length: %d\r\n\r\n%s", len(body), body); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+func (c *client) read() *message {
+	header, err := textproto.NewReader(c.r).ReadMIMEHeader()
+	if err != nil {
+		c.t.Fatalf("reading header, %s", err)
+	}
+	length, _ := strconv.Atoi(header.Get("Content-Length"))
+	body := make([]byte, length)
+	if _, err := io.ReadFull(c.r, body); err != nil {
+		c.t.Fatalf("reading body, %s", err)
+	}
+	msg := new(message)
+	if err := json.Unmarshal(body, msg); err != nil {
+		c.t.Fatalf("decoding %s, %s", body, err)
+	}
+	if msg.Result != nil {
+		// Keep the raw result so it can be decoded into the expected type
+		var raw struct {
+			Result json.RawMessage `json:"result"`
+		}
+		json.Unmarshal(body, &raw)
+		msg.Result = raw.Result
+	}
+	return msg
+}
+
+// notification sends a notification and collects the notifications the
+// server sends back
+func (c *client) notification(method string, params interface{}, expected int) {
+	c.send(map[string]interface{}{"method": method, "params": params})
+	for i := 0; i < expected; i++ {
+		c.notifications = append(c.notifications, c.read())
+	}
+}
+
+// call sends a request and decodes the result into result
+func (c *client) call(method string, params interface{}, result interface{}) {
+	c.id++
+	c.send(map[string]interface{}{"id": c.id, "method": method, "params": params})
+	for {
+		msg := c.read()
+		if msg.ID == nil {
+			c.notifications = append(c.notifications, msg)
+			continue
+		}
+		if msg.Error != nil {
+			c.t.Fatalf("%s failed, %s", method, msg.Error.Message)
+		}
+		if result != nil {
+			if err := json.Unmarshal(msg.Result.(json.RawMessage), result); err != nil {
+				c.t.Fatalf("%s result %s, %s", method, msg.Result, err)
+			}
+		}
+		return
+	}
+}
+
+const testDocument = `Title: LSP
+
+FADE IN:
+
+# Act One
+
+## Morning
+
+INT. KITCHEN - DAY #1#
+
+BOB
+The pot is hot.
+
+ALICE
+So it is.
+
+/* Cut the
+next scene */
+
+# Act Two
+
+EXT. GARDEN
+
+[[A note
+over two lines]]
+
+BOBB
+`
+
+func TestServer(t *testing.T) {
+	c := newClient(t)
+	uri := "file:///tmp/test.fountain"
+	var initialized struct {
+		Capabilities map[string]interface{} `json:"capabilities"`
+	}
+	c.call("initialize", map[string]interface{}{"capabilities": map[string]interface{}{}}, &initialized)
+	for _, capability := range []string{"documentSymbolProvider", "completionProvider", "foldingRangeProvider", "documentFormattingProvider"} {
+		if _, ok := initialized.Capabilities[capability]; !ok {
+			t.Errorf("expected %s in capabilities", capability)
+		}
+	}
+	c.notification("initialized", map[string]interface{}{}, 0)
+
+	// Opening a document publishes its diagnostics
+	c.notification("textDocument/didOpen", map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": uri, "languageId": "fountain", "version": 1, "text": testDocument},
+	}, 1)
+	var published struct {
+		URI         string        `json:"uri"`
+		Diagnostics []*diagnostic `json:"diagnostics"`
+	}
+	if msg := c.notifications[0]; msg.Method != "textDocument/publishDiagnostics" {
+		t.Fatalf("expected diagnostics, got %s", msg.Method)
+	} else if err := json.Unmarshal(msg.Params, &published); err != nil {
+		t.Fatal(err)
+	}
+	codes := []string{}
+	for _, d := range published.Diagnostics {
+		codes = append(codes, fmt.Sprintf("%d:%s", d.Range.Start.Line, d.Code))
+	}
+	if got := strings.Join(codes, ","); got != "21:missing-time-of-day,26:cue-without-dialogue" {
+		t.Errorf("unexpected diagnostics %s", got)
+	}
+
+	// The outline nests the scenes in the sections
+	symbols := []*documentSymbol{}
+	c.call("textDocument/documentSymbol", map[string]interface{}{"textDocument": map[string]string{"uri": uri}}, &symbols)
+	src, _ := json.Marshal(symbols)
+	if len(symbols) != 2 || symbols[0].Name != "Act One" || symbols[1].Name != "Act Two" {
+		t.Fatalf("expected two acts, got %s", src)
+	}
+	morning := symbols[0].Children
+	if len(morning) != 1 || morning[0].Name != "Morning" || len(morning[0].Children) != 1 {
+		t.Fatalf("expected Morning section holding a scene, got %+v", morning)
+	}
+	if scene := morning[0].Children[0]; scene.Name != "INT. KITCHEN - DAY" || scene.Detail != "#1" || scene.Range.Start.Line != 8 || scene.Range.End.Line != 18 {
+		t.Errorf("unexpected scene symbol %+v", scene)
+	}
+	if act := symbols[1]; len(act.Children) != 1 || act.Children[0].Name != "EXT. GARDEN" {
+		t.Errorf("unexpected Act Two %+v", act)
+	}
+
+	// Completion offers the character names
+	items := []*completionItem{}
+	c.call("textDocument/completion", map[string]interface{}{
+		"textDocument": map[string]string{"uri": uri},
+		"position":     map[string]int{"line": 27, "character": 2},
+	}, &items)
+	names := []string{}
+	for _, item := range items {
+		names = append(names, item.Label)
+	}
+	if strings.Join(names, ",") != "ALICE,BOB,BOBB" {
+		t.Errorf("expected ALICE, BOB and BOBB, got %s", strings.Join(names, ","))
+	}
+
+	// Boneyard and notes fold
+	ranges := []*foldingRange{}
+	c.call("textDocument/foldingRange", map[string]interface{}{"textDocument": map[string]string{"uri": uri}}, &ranges)
+	if len(ranges) != 2 || ranges[0].StartLine != 16 || ranges[0].EndLine != 17 || ranges[1].StartLine != 23 || ranges[1].EndLine != 24 {
+		t.Errorf("unexpected folding ranges %+v %+v", ranges[0], ranges[1])
+	}
+
+	// Formatting replaces the document with the fountainfmt output
+	edits := []*textEdit{}
+	src = []byte("INT. KITCHEN - DAY\n\n\n\nBOB\nHi.\n")
+	c.notification("textDocument/didChange", map[string]interface{}{
+		"textDocument":   map[string]interface{}{"uri": uri, "version": 2},
+		"contentChanges": []map[string]string{{"text": string(src)}},
+	}, 1)
+	c.call("textDocument/formatting", map[string]interface{}{"textDocument": map[string]string{"uri": uri}}, &edits)
+	if len(edits) != 1 || edits[0].Range.Start.Line != 0 || edits[0].Range.End.Line != 6 {
+		t.Fatalf("expected an edit replacing the document, got %+v", edits)
+	}
+	if expected := "INT. KITCHEN - DAY\n\n\n\n                BOB\n        Hi.\n"; edits[0].NewText != expected {
+		t.Errorf("expected formatted text %q, got %q", expected, edits[0].NewText)
+	}
+
+	// and formatting it again changes nothing
+	c.notification("textDocument/didChange", map[string]interface{}{
+		"textDocument":   map[string]interface{}{"uri": uri, "version": 3},
+		"contentChanges": []map[string]string{{"text": testDocument}},
+	}, 1)
+	c.call("textDocument/formatting", map[string]interface{}{"textDocument": map[string]string{"uri": uri}}, &edits)
+	if len(edits) != 1 {
+		t.Fatalf("expected an edit replacing the document, got %+v", edits)
+	}
+	c.notification("textDocument/didChange", map[string]interface{}{
+		"textDocument":   map[string]interface{}{"uri": uri, "version": 4},
+		"contentChanges": []map[string]string{{"text": edits[0].NewText}},
+	}, 1)
+	c.call("textDocument/formatting", map[string]interface{}{"textDocument": map[string]string{"uri": uri}}, &edits)
+	if len(edits) != 0 {
+		t.Errorf("expected no edits formatting a formatted document, got %q", edits[0].NewText)
+	}
+
+	// Unknown requests get an error
+	c.id++
+	c.send(map[string]interface{}{"id": c.id, "method": "textDocument/hover", "params": map[string]interface{}{}})
+	if msg := c.read(); msg.Error == nil || msg.Error.Code != methodNotFound {
+		t.Errorf("expected method not found, got %+v", msg)
+	}
+
+	c.call("shutdown", nil, nil)
+	c.send(map[string]interface{}{"method": "exit"})
+	if err := <-c.done; err != nil {
+		t.Errorf("serve returned %s", err)
+	}
+}
@@ -38,7 +38,6 @@ package main
 import (
 	"flag"
 	"fmt"
-	"io/ioutil"
 	"os"
 	"path"
 
@@ -98,6 +97,12 @@ var (
 -width
 : set the width for the text
 
+-notes
+: include notes in output
+
+-boneyard
+: include boneyard in output
+
 
 # EXAMPLES
 
@@ -125,11 +130,13 @@ Or alternatively
 	outputFName      string
 
 	// App Option
-	asHTMLPage bool
-	inlineCSS  bool
-	linkCSS    bool
-	includeCSS string
-	width      int
+	asHTMLPage   bool
+	inlineCSS    bool
+	linkCSS      bool
+	includeCSS   string
+	width        int
+	showNotes    bool
+	showBoneyard bool
 )
 
 func main() {
@@ -155,6 +162,8 @@ func main() {
 	flag.BoolVar(&linkCSS, "link-css", false, "Add a link to CSS (default CSS is fountain.css)")
 	flag.StringVar(&includeCSS, "css", "fountain.css", "Include a custom CSS file")
 	flag.IntVar(&width, "width", 65, "set the width for the text")
+	flag.BoolVar(&showNotes, "notes", false, "include notes in output")
+	flag.BoolVar(&showBoneyard, "boneyard", false, "include boneyard in output")
 
 	// Parse environment and options
 	flag.Parse()
@@ -197,28 +206,24 @@ func main() {
 		os.Exit(0)
 	}
 
-	// ReadAll of input
-	src, err := ioutil.ReadAll(in)
-	if err != nil {
-		fmt.Fprintf(eout, "%s\n", err)
-		os.Exit(1)
-	}
-	
 	// Override defaults
-	fountain.AsHTMLPage = asHTMLPage
-	fountain.MaxWidth = width
-	fountain.InlineCSS = inlineCSS
-	fountain.LinkCSS = linkCSS
-	fountain.CSS = includeCSS
+	opt := fountain.DefaultOptions()
+	opt.AsHTMLPage = asHTMLPage
+	opt.MaxWidth = width
+	opt.InlineCSS = inlineCSS
+	opt.LinkCSS = linkCSS
+	opt.CSS = includeCSS
+	opt.ShowNotes = showNotes
+	opt.ShowBoneyard = showBoneyard
 	// Parse  input and render screenplay
-	screenplay, err := fountain.Run(src)
+	screenplay, err := fountain.NewDecoder(in).Decode()
 	if err != nil {
 		fmt.Fprintf(eout, "%s\n", err)
 		os.Exit(1)
 	}
 
 	//and then render as a string
-	fmt.Fprintf(out, "%s", screenplay)
+	fmt.Fprintf(out, "%s", screenplay.RenderHTML(opt))
 	if newLine {
 		fmt.Fprintln(out)
 	}
@@ -34,9 +34,10 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
-	"io/ioutil"
+	"io"
 	"os"
 	"path"
 
@@ -88,6 +89,9 @@ var (
 -pretty
 : pretty print the output
 
+-ndjson
+: stream each element as a line of JSON (newline delimited JSON)
+
 
 # EXAMPLES
 
@@ -117,6 +121,7 @@ Or alternatively
 	// App Option
 	width       int
 	prettyPrint bool
+	ndJSON      bool
 )
 
 func main() {
@@ -139,6 +144,7 @@ func main() {
 	// App Option
 	flag.BoolVar(&prettyPrint, "pretty", false, "pretty print the JSON output")
 	flag.IntVar(&width, "width", 65, "set the width for the text")
+	flag.BoolVar(&ndJSON, "ndjson", false, "stream each element as a line of JSON")
 
 	// Parse environment and options
 	flag.Parse()
@@ -182,22 +188,38 @@ func main() {
 		os.Exit(0)
 	}
 
-	// ReadAll of input
-	src, err := ioutil.ReadAll(in)
-	if err != nil {
-		fmt.Fprintf(eout, "%s\n", err)
-		os.Exit(1)
+	// Stream elements as they are parsed
+	decoder := fountain.NewDecoder(in)
+	if ndJSON {
+		encoder := json.NewEncoder(out)
+		for {
+			element, err := decoder.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				fmt.Fprintf(eout, "%s\n", err)
+				os.Exit(1)
+			}
+			if err := encoder.Encode(element); err != nil {
+				fmt.Fprintf(eout, "%s\n", err)
+				os.Exit(1)
+			}
+		}
+		os.Exit(0)
 	}
 
 	// Parse input
-	screenplay, err := fountain.Parse(src)
+	screenplay, err := decoder.Decode()
 	if err != nil {
 		fmt.Fprintf(eout, "%s\n", err)
 		os.Exit(1)
 	}
 
-	fountain.PrettyPrint = prettyPrint
-	src, err = screenplay.ToJSON()
+	opt := fountain.DefaultOptions()
+	opt.MaxWidth = width
+	opt.PrettyPrint = prettyPrint
+	src, err := screenplay.RenderJSON(opt)
 	if err != nil {
 		fmt.Fprintf(eout, "%s\n", err)
 		os.Exit(1)
@@ -0,0 +1,303 @@
+//
+// fountainconv converts screenplays between Fountain and the other supported formats.
+//
+//
+// fountain is a package encoding/decoding fountain formatted screenplays.
+//
+// @author R. S. Doiel, <rsdoiel@gmail.com>
+//
+// BSD 2-Clause License
+//
+// Copyright (c) 2019, R. S. Doiel
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+//
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	// My packages
+	"github.com/rsdoiel/fountain"
+)
+
+var (
+	helpText = `%{app_name}(1) | version {version} {release_hash}
+% R. S. Doiel
+% {release_date}
+
+# NAME
+
+{app_name}
+
+# SYNOPSIS
+
+{app_name} [OPTIONS]
+
+# DESCRIPTION
+
+{app_name} is a command line program that converts a screenplay from one format to another. The formats are taken from the -from and -to options or from the input and output filename extensions.
+
+Formats read
+
+fountain
+: Fountain (.fountain, .spmd, .txt)
+
+fdx
+: Final Draft (.fdx)
+
+fadein
+: FadeIn (.fadein)
+
+osf
+: Open Screenplay Format XML (.osf, .xml)
+
+json
+: JSON as written by fountain2json (.json)
+
+yaml
+: YAML as written by fountain2yaml (.yaml, .yml)
+
+Formats written are the ones read plus
+
+html
+: HTML (.html, .htm)
+
+pdf
+: PDF (.pdf)
+
+# OPTIONS
+
+-help
+: display help
+
+-license
+: display license
+
+-version
+: display version
+
+-i
+: read from input file
+
+-o
+: write to output file
+
+-from
+: set the input format (default from the input filename or fountain)
+
+-to
+: set the output format (default from the output filename or fountain)
+
+
+# EXAMPLES
+
+Convert a Final Draft *screenplay.fdx* to *screenplay.fadein*.
+
+~~~
+    {app_name} -i screenplay.fdx -o screenplay.fadein
+~~~
+
+Or alternatively
+
+~~~
+    cat screenplay.fdx | {app_name} -from fdx -to fadein >screenplay.fadein
+~~~
+
+`
+
+	// Standard Options
+	showHelp    bool
+	showLicense bool
+	showVersion bool
+	quiet       bool
+	inputFName  string
+	outputFName string
+
+	// App Option
+	from string
+	to   string
+)
+
+// formats maps filename extensions to format names
+var formats = map[string]string{
+	".fountain": "fountain",
+	".spmd":     "fountain",
+	".txt":      "fountain",
+	".fdx":      "fdx",
+	".fadein":   "fadein",
+	".osf":      "osf",
+	".xml":      "osf",
+	".json":     "json",
+	".yaml":     "yaml",
+	".yml":      "yaml",
+	".html":     "html",
+	".htm":      "html",
+	".pdf":      "pdf",
+}
+
+// formatOf returns the format for a filename, "fountain" if it is not known
+func formatOf(fname string) string {
+	if format, ok := formats[strings.ToLower(filepath.Ext(fname))]; ok {
+		return format
+	}
+	return "fountain"
+}
+
+// readScreenplay parses src in the given format
+func readScreenplay(format string, src []byte) (*fountain.Fountain, error) {
+	switch format {
+	case "fountain":
+		return fountain.Parse(src)
+	case "fdx":
+		return fountain.ParseFDX(src)
+	case "fadein":
+		return fountain.ParseFadeIn(src)
+	case "osf":
+		return fountain.ParseOSF(src)
+	case "json":
+		return fountain.FromJSON(src)
+	case "yaml":
+		return fountain.FromYAML(src)
+	}
+	return nil, fmt.Errorf("%q is not a supported input format", format)
+}
+
+// writeScreenplay renders the screenplay in the given format
+func writeScreenplay(format string, screenplay *fountain.Fountain) ([]byte, error) {
+	opt := fountain.DefaultOptions()
+	switch format {
+	case "fountain":
+		return []byte(screenplay.RenderString(opt) + "\n"), nil
+	case "fdx":
+		return screenplay.ToFDX()
+	case "fadein":
+		return screenplay.ToFadeIn()
+	case "osf":
+		return screenplay.ToOSF()
+	case "json":
+		opt.PrettyPrint = true
+		return screenplay.RenderJSON(opt)
+	case "yaml":
+		return screenplay.ToYAML()
+	case "html":
+		opt.AsHTMLPage = true
+		opt.InlineCSS = true
+		return []byte(screenplay.RenderHTML(opt)), nil
+	case "pdf":
+		return screenplay.RenderPDF(opt)
+	}
+	return nil, fmt.Errorf("%q is not a supported output format", format)
+}
+
+func main() {
+	appName := path.Base(os.Args[0])
+	// NOTE: These are set when version.go is generated
+	version := fountain.Version
+	releaseDate := fountain.ReleaseDate
+	releaseHash := fountain.ReleaseHash
+	fmtHelp := fountain.FmtHelp
+
+	// Standard Options
+	flag.BoolVar(&showHelp, "help", false, "display help")
+	flag.BoolVar(&showLicense, "license", false, "display license")
+	flag.BoolVar(&showVersion, "version", false, "display version")
+	flag.BoolVar(&quiet, "quiet", false, "suppress error messages")
+	flag.StringVar(&inputFName, "i", "", "set the input filename")
+	flag.StringVar(&outputFName, "o", "", "set the output filename")
+
+	// App Option
+	flag.StringVar(&from, "from", "", "set the input format")
+	flag.StringVar(&to, "to", "", "set the output format")
+
+	// Parse environment and options
+	flag.Parse()
+
+	// Setup IO
+	var err error
+
+	in := os.Stdin
+	out := os.Stdout
+	eout := os.Stderr
+
+	if inputFName != "" {
+		in, err = os.Open(inputFName)
+		if err != nil {
+			fmt.Fprintf(eout, "%s\n", err)
+			os.Exit(1)
+		}
+		defer in.Close()
+	}
+	if outputFName != "" {
+		out, err = os.Create(outputFName)
+		if err != nil {
+			fmt.Fprintf(eout, "%s\n", err)
+			os.Exit(1)
+		}
+		defer out.Close()
+	}
+
+	// Process options
+	if showHelp {
+		fmt.Fprintf(out, "%s\n", fmtHelp(helpText, appName, version, releaseDate, releaseHash))
+		os.Exit(0)
+	}
+	if showLicense {
+		fmt.Fprintf(out, "%s\n", fountain.LicenseText)
+		os.Exit(0)
+	}
+	if showVersion {
+		fmt.Fprintf(out, "%s %s %s\n", appName, version, releaseHash)
+		os.Exit(0)
+	}
+	if from == "" {
+		from = formatOf(inputFName)
+	}
+	if to == "" {
+		to = formatOf(outputFName)
+	}
+
+	// Read, convert and write the screenplay
+	src, err := io.ReadAll(in)
+	if err != nil {
+		fmt.Fprintf(eout, "%s\n", err)
+		os.Exit(1)
+	}
+	screenplay, err := readScreenplay(strings.ToLower(from), src)
+	if err != nil {
+		fmt.Fprintf(eout, "%s\n", err)
+		os.Exit(1)
+	}
+	src, err = writeScreenplay(strings.ToLower(to), screenplay)
+	if err != nil {
+		fmt.Fprintf(eout, "%s\n", err)
+		os.Exit(1)
+	}
+	out.Write(src)
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// lcs returns the length of the longest common subsequence of a and b
+func lcs(a []string, b []string) int {
+	row := make([]int, len(b)+1)
+	for i := range a {
+		prev := 0
+		for j := range b {
+			current := row[j+1]
+			if a[i] == b[j] {
+				row[j+1] = prev + 1
+			} else {
+				row[j+1] = max(row[j+1], row[j])
+			}
+			prev = current
+		}
+	}
+	return row[len(b)]
+}
+
+func TestDiffLines(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	words := []string{"INT. HOUSE\n", "\n", "BOB\n", "Hi.\n", "CUT TO:\n"}
+	for n := 0; n < 500; n++ {
+		a, b := []string{}, []string{}
+		for i := r.Intn(12); i > 0; i-- {
+			a = append(a, words[r.Intn(len(words))])
+		}
+		for i := r.Intn(12); i > 0; i-- {
+			b = append(b, words[r.Intn(len(words))])
+		}
+		edits := diffLines(a, b)
+		gotA, gotB, kept := []string{}, []string{}, 0
+		for _, e := range edits {
+			if e.op != '+' {
+				gotA = append(gotA, e.line)
+			}
+			if e.op != '-' {
+				gotB = append(gotB, e.line)
+			}
+			if e.op == ' ' {
+				kept++
+			}
+		}
+		if strings.Join(gotA, "") != strings.Join(a, "") || strings.Join(gotB, "") != strings.Join(b, "") {
+			t.Fatalf("edits %q do not turn %q into %q", edits, a, b)
+		}
+		if expected := lcs(a, b); kept != expected {
+			t.Fatalf("expected %d lines kept from %q to %q, got %d", expected, a, b, kept)
+		}
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	a := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n14\n15\n16"
+	b := "1\n2\n3\nfour\n5\n6\n7\n8\n9\n10\n11\n12\n13\n14\n15\n16\n"
+	expected := `diff -u x.fountain.orig x.fountain
+--- x.fountain.orig
++++ x.fountain
+@@ -1,7 +1,7 @@
+ 1
+ 2
+ 3
+-4
++four
+ 5
+ 6
+ 7
+@@ -13,4 +13,4 @@
+ 13
+ 14
+ 15
+-16
+\ No newline at end of file
++16
+`
+	if got := unifiedDiff("x.fountain", a, b); got != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, got)
+	}
+	if got := unifiedDiff("x.fountain", a, a); got != "" {
+		t.Errorf("expected no diff, got\n%s", got)
+	}
+	expected = "diff -u x.fountain.orig x.fountain\n--- x.fountain.orig\n+++ x.fountain\n@@ -0,0 +1 @@\n+new\n"
+	if got := unifiedDiff("x.fountain", "", "new\n"); got != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, got)
+	}
+}
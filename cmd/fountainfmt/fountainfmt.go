@@ -35,11 +35,15 @@
 package main
 
 import (
+	"bytes"
 	"flag"
 	"fmt"
-	"io/ioutil"
+	"io"
+	"io/fs"
 	"os"
 	"path"
+	"path/filepath"
+	"strings"
 
 	// My packages
 	"github.com/rsdoiel/fountain"
@@ -56,11 +60,17 @@ var (
 
 # SYNOPSIS
 
-{app_name} [OPTIONS]
+{app_name} [OPTIONS] [FILE|DIRECTORY ...]
 
 # DESCRIPTION
 
 {app_name} is a command line program that reads an fountain document and pretty prints it.
+Forcing markers (e.g. "!" for action, "@" for a character) are only
+written where they are needed for the element to be read back the same.
+
+Like gofmt it can also check or rewrite many files. The files named and
+the fountain files (.fountain, .spmd) found in the directories named are
+formatted, by default the result is written to standard output.
 
 # OPTIONS
 
@@ -86,7 +96,7 @@ var (
 : set text width
 
 -debug
-: display type and element content
+: display source position, type and element content
 
 -section
 : include sections in output
@@ -95,7 +105,28 @@ var (
 : include synopsis in output
 
 -notes
-: include notes in output
+: include notes ([[ ... ]]) in output (default true)
+
+-boneyard
+: include boneyard (/\* ... \*/) in output (default true)
+
+-number-scenes
+: number scenes missing a scene number, existing numbers are kept
+
+-renumber-scenes
+: renumber all scenes sequentially
+
+-check
+: list the files that are not formatted and exit with an error if there are any (e.g. in a pre-commit hook)
+
+-d
+: display a unified diff of the changes instead of the formatted screenplay
+
+-w
+: write the formatted screenplay back to the file if it has changed
+
+-lossless
+: write the screenplay back as it was written keeping the white space, forced markers, notes, sections and boneyard. Only what is asked for (e.g. -number-scenes) is changed, -width, -newline, -section, -synopsis, -notes and -boneyard are not used.
 
 
 # EXAMPLES
@@ -112,6 +143,25 @@ Or alternatively
 cat screenplay.txt | {app_name} > screenplay.fountain
 ~~~
 
+Number the scenes of *screenplay.fountain* leaving the rest as written.
+
+~~~
+{app_name} -lossless -number-scenes -i screenplay.fountain
+~~~
+
+Check all the scripts in the *scripts* directory are formatted, showing
+what needs changing.
+
+~~~
+{app_name} -check -d scripts
+~~~
+
+Format them in place.
+
+~~~
+{app_name} -w scripts
+~~~
+
 `
 
 	// Standard Options
@@ -124,13 +174,132 @@ cat screenplay.txt | {app_name} > screenplay.fountain
 	outputFName      string
 
 	// App Option
-	width        int
-	debug        bool
-	showSection  bool
-	showSynopsis bool
-	showNotes    bool
+	width          int
+	debug          bool
+	showSection    bool
+	showSynopsis   bool
+	showNotes      bool
+	showBoneyard   bool
+	numberScenes   bool
+	renumberScenes bool
+	lossless       bool
+	check          bool
+	showDiff       bool
+	write          bool
 )
 
+// fountainFiles returns the files named and the fountain files found
+// in the directories named
+func fountainFiles(paths []string) ([]string, error) {
+	fnames := []string{}
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			fnames = append(fnames, p)
+			continue
+		}
+		err = filepath.WalkDir(p, func(fname string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			switch strings.ToLower(filepath.Ext(fname)) {
+			case ".fountain", ".spmd":
+				if !entry.IsDir() {
+					fnames = append(fnames, fname)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return fnames, nil
+}
+
+// format parses src and returns the formatted screenplay
+func format(src []byte, opt *fountain.Options) ([]byte, error) {
+	screenplay, err := fountain.Parse(src)
+	if err != nil {
+		return nil, err
+	}
+	if numberScenes || renumberScenes {
+		screenplay.NumberScenes(renumberScenes)
+	}
+	if lossless {
+		return []byte(screenplay.RenderSource(opt)), nil
+	}
+	if newLine {
+		return []byte(screenplay.RenderString(opt) + "\n"), nil
+	}
+	return []byte(screenplay.RenderString(opt)), nil
+}
+
+// formatFile formats a file (src is read from it if nil) writing the
+// result to out, a diff with -d or back to the file with -w. It returns
+// true if the file was not formatted.
+func formatFile(out io.Writer, fname string, src []byte, opt *fountain.Options) (bool, error) {
+	var err error
+	if src == nil {
+		if src, err = os.ReadFile(fname); err != nil {
+			return false, err
+		}
+	}
+	res, err := format(src, opt)
+	if err != nil {
+		return false, fmt.Errorf("%s: %s", fname, err)
+	}
+	if !check && !showDiff && !write {
+		_, err = out.Write(res)
+		return false, err
+	}
+	if bytes.Equal(src, res) {
+		return false, nil
+	}
+	if check {
+		fmt.Fprintln(out, fname)
+	}
+	if showDiff {
+		fmt.Fprint(out, unifiedDiff(fname, string(src), string(res)))
+	}
+	if write {
+		info, err := os.Stat(fname)
+		if err != nil {
+			return true, err
+		}
+		if err := os.WriteFile(fname, res, info.Mode().Perm()); err != nil {
+			return true, err
+		}
+	}
+	return true, nil
+}
+
+// formatFiles formats the files and directories named, it returns the
+// exit code, 1 if there was an error or, with -check, a file was not
+// formatted.
+func formatFiles(out io.Writer, eout io.Writer, paths []string, opt *fountain.Options) int {
+	fnames, err := fountainFiles(paths)
+	if err != nil {
+		fmt.Fprintf(eout, "%s\n", err)
+		return 1
+	}
+	exitCode := 0
+	for _, fname := range fnames {
+		changed, err := formatFile(out, fname, nil, opt)
+		if err != nil {
+			fmt.Fprintf(eout, "%s\n", err)
+			exitCode = 1
+		}
+		if changed && check {
+			exitCode = 1
+		}
+	}
+	return exitCode
+}
+
 func main() {
 	appName := path.Base(os.Args[0])
 	// NOTE: the following are set when version.go is generated
@@ -149,11 +318,18 @@ func main() {
 	flag.StringVar(&outputFName, "o", "", "set the output filename")
 
 	// App Option
-	flag.BoolVar(&debug, "debug", false, "display type and element content")
+	flag.BoolVar(&debug, "debug", false, "display source position, type and element content")
 	flag.IntVar(&width, "width", 65, "set the width for the text")
 	flag.BoolVar(&showSection, "section", false, "include sections in output")
 	flag.BoolVar(&showSynopsis, "synopsis", false, "include synopsis in output")
-	flag.BoolVar(&showNotes, "notes", false, "include notes in output")
+	flag.BoolVar(&showNotes, "notes", true, "include notes in output")
+	flag.BoolVar(&showBoneyard, "boneyard", true, "include boneyard in output")
+	flag.BoolVar(&numberScenes, "number-scenes", false, "number scenes missing a scene number, existing numbers are kept")
+	flag.BoolVar(&renumberScenes, "renumber-scenes", false, "renumber all scenes sequentially")
+	flag.BoolVar(&lossless, "lossless", false, "write the screenplay back as it was written, only changing what is asked for")
+	flag.BoolVar(&check, "check", false, "list the files that are not formatted and exit with an error if there are any")
+	flag.BoolVar(&showDiff, "d", false, "display a unified diff of the changes")
+	flag.BoolVar(&write, "w", false, "write the result back to the file")
 
 	// Parse environment and options
 	flag.Parse()
@@ -198,34 +374,62 @@ func main() {
 	}
 
 	// Setup options
-	fountain.MaxWidth = width
-	fountain.ShowSection = showSection
-	fountain.ShowSynopsis = showSynopsis
-	fountain.ShowNotes = showNotes
-
-	// ReadAll of input
-	src, err := ioutil.ReadAll(in)
-	if err != nil {
-		fmt.Fprintf(eout, "%s\n", err)
-		os.Exit(1)
+	opt := fountain.DefaultOptions()
+	opt.MaxWidth = width
+	opt.ShowSection = showSection
+	opt.ShowSynopsis = showSynopsis
+	opt.ShowNotes = showNotes
+	opt.ShowBoneyard = showBoneyard
+
+	// Format the files and directories named, like gofmt
+	paths := flag.Args()
+	if len(paths) == 0 && write {
+		if inputFName == "" {
+			fmt.Fprintf(eout, "-w needs a file or directory to rewrite\n")
+			os.Exit(1)
+		}
+		paths = []string{inputFName}
 	}
-	
-	// Parse input
-	screenplay, err := fountain.Parse(src)
+	if len(paths) > 0 {
+		os.Exit(formatFiles(out, eout, paths, opt))
+	}
+
+	// Read input
+	src, err := io.ReadAll(in)
 	if err != nil {
 		fmt.Fprintf(eout, "%s\n", err)
 		os.Exit(1)
 	}
 
-	//and then render as a string
 	if debug {
+		screenplay, err := fountain.Parse(src)
+		if err != nil {
+			fmt.Fprintf(eout, "%s\n", err)
+			os.Exit(1)
+		}
+		if numberScenes || renumberScenes {
+			screenplay.NumberScenes(renumberScenes)
+		}
+		for i, element := range screenplay.TitlePage {
+			fmt.Fprintf(out, "%4d %s-%s %02d %s: %q\n", i, element.Start, element.End, element.Type, element.Name, element.Content)
+		}
 		for i, element := range screenplay.Elements {
-			fmt.Fprintf(out, "%4d %02d %q\n", i, element.Type, element.Content)
+			fmt.Fprintf(out, "%4d %s-%s %02d %q\n", i, element.Start, element.End, element.Type, element.Content)
 		}
 		os.Exit(0)
 	}
-	fmt.Fprintf(out, "%s", screenplay.String())
-	if newLine {
-		fmt.Fprintln(out)
+
+	//and then render as a string
+	name := inputFName
+	if name == "" {
+		name = "<standard input>"
+	}
+	changed, err := formatFile(out, name, src, opt)
+	if err != nil {
+		fmt.Fprintf(eout, "%s\n", err)
+		os.Exit(1)
+	}
+	if changed && check {
+		os.Exit(1)
 	}
 }
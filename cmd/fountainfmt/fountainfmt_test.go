@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	// My packages
+	"github.com/rsdoiel/fountain"
+)
+
+func TestCheckWrite(t *testing.T) {
+	// The flags as set by default
+	newLine, showNotes, showBoneyard = true, true, true
+	defer func() { check, write, showDiff = false, false, false }()
+	opt := fountain.DefaultOptions()
+	opt.MaxWidth = 65
+	opt.ShowNotes = showNotes
+	opt.ShowBoneyard = showBoneyard
+
+	dir := t.TempDir()
+	fnames, err := filepath.Glob(filepath.Join("..", "..", "testdata", "*.fountain"))
+	if err != nil || len(fnames) == 0 {
+		t.Fatalf("expected the samples, got %d, %v", len(fnames), err)
+	}
+	for _, fname := range fnames {
+		src, err := os.ReadFile(fname)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, filepath.Base(fname)), src, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	run := func() (int, string) {
+		out, eout := new(bytes.Buffer), new(bytes.Buffer)
+		exitCode := formatFiles(out, eout, []string{dir}, opt)
+		if eout.Len() > 0 {
+			t.Errorf("expected no errors, got %s", eout)
+		}
+		return exitCode, out.String()
+	}
+
+	// The samples are not formatted
+	check = true
+	if exitCode, out := run(); exitCode != 1 || !strings.Contains(out, "sample-07.fountain") {
+		t.Errorf("expected -check to exit 1 listing sample-07.fountain, got %d\n%s", exitCode, out)
+	}
+
+	check, write = false, true
+	if exitCode, out := run(); exitCode != 0 || out != "" {
+		t.Errorf("expected -w to exit 0 writing nothing out, got %d\n%s", exitCode, out)
+	}
+
+	// and are once written back
+	check, write = true, false
+	if exitCode, out := run(); exitCode != 0 || out != "" {
+		t.Errorf("expected -check to pass after -w, got %d\n%s", exitCode, out)
+	}
+	check, showDiff = false, true
+	if exitCode, out := run(); exitCode != 0 || out != "" {
+		t.Errorf("expected no diff after -w, got %d\n%s", exitCode, out)
+	}
+
+	// A file that can't be read is an error
+	out, eout := new(bytes.Buffer), new(bytes.Buffer)
+	if exitCode := formatFiles(out, eout, []string{filepath.Join(dir, "missing.fountain")}, opt); exitCode != 1 || eout.Len() == 0 {
+		t.Errorf("expected an error and exit 1 for a missing file, got %d", exitCode)
+	}
+}
@@ -0,0 +1,245 @@
+//
+// fountainlint checks a Fountain file for markup that is likely to be misread.
+//
+//
+// fountain is a package encoding/decoding fountain formatted screenplays.
+//
+// @author R. S. Doiel, <rsdoiel@gmail.com>
+//
+// BSD 2-Clause License
+//
+// Copyright (c) 2019, R. S. Doiel
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+//
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	// My packages
+	"github.com/rsdoiel/fountain"
+)
+
+var (
+	helpText = `%{app_name}(1) | version {version} {release_hash}
+% R. S. Doiel
+% {release_date}
+
+# NAME
+
+{app_name}
+
+# SYNOPSIS
+
+{app_name} [OPTIONS] [FOUNTAIN_FILE ...]
+
+# DESCRIPTION
+
+{app_name} is a command line program that checks fountain documents for markup that is likely to be misread. It reports ambiguous lines (e.g. action directly after dialogue, a transition read as action), unterminated notes (\[\[) and boneyard (/\*), character cues without dialogue, scene headings without a time of day, inconsistent spellings of character names and unbalanced emphasis.
+
+Each problem is reported with its line, column and severity (error or warning). The default output is GNU style, FILE:LINE:COLUMN: SEVERITY: MESSAGE, understood by most editors. The JSON output is a list of diagnostics for use in continuous integration.
+
+If no files are given the fountain document is read from standard input. {app_name} exits with 1 if any problems are found.
+
+# OPTIONS
+
+-help
+: display help
+
+-license
+: display license
+
+-version
+: display version
+
+-i
+: read from input file
+
+-o
+: write to output file
+
+-format
+: set the output format, gnu or json (default gnu)
+
+
+# EXAMPLES
+
+Check *screenplay.fountain*.
+
+~~~
+    {app_name} screenplay.fountain
+~~~
+
+Check all the fountain files in the current directory writing JSON.
+
+~~~
+    {app_name} -format json *.fountain
+~~~
+
+`
+
+	// Standard Options
+	showHelp    bool
+	showLicense bool
+	showVersion bool
+	quiet       bool
+	inputFName  string
+	outputFName string
+
+	// App Option
+	format string
+)
+
+// fileDiagnostic is a diagnostic with the name of the file it was found in
+type fileDiagnostic struct {
+	File string `json:"file"`
+	*fountain.Diagnostic
+}
+
+// lint parses the fountain document read from in and returns its
+// diagnostics
+func lint(fname string, in io.Reader) ([]*fileDiagnostic, error) {
+	screenplay, err := fountain.NewDecoder(in).Decode()
+	if err != nil {
+		return nil, err
+	}
+	diagnostics := []*fileDiagnostic{}
+	for _, d := range fountain.Lint(screenplay) {
+		diagnostics = append(diagnostics, &fileDiagnostic{File: fname, Diagnostic: d})
+	}
+	return diagnostics, nil
+}
+
+// lintFile checks a file
+func lintFile(fname string) ([]*fileDiagnostic, error) {
+	in, err := os.Open(fname)
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+	return lint(fname, in)
+}
+
+func main() {
+	appName := path.Base(os.Args[0])
+	// NOTE: These are set when version.go is generated
+	version := fountain.Version
+	releaseDate := fountain.ReleaseDate
+	releaseHash := fountain.ReleaseHash
+	fmtHelp := fountain.FmtHelp
+
+	// Standard Options
+	flag.BoolVar(&showHelp, "help", false, "display help")
+	flag.BoolVar(&showLicense, "license", false, "display license")
+	flag.BoolVar(&showVersion, "version", false, "display version")
+	flag.BoolVar(&quiet, "quiet", false, "suppress error messages")
+	flag.StringVar(&inputFName, "i", "", "set the input filename")
+	flag.StringVar(&outputFName, "o", "", "set the output filename")
+
+	// App Option
+	flag.StringVar(&format, "format", "gnu", "set the output format, gnu or json")
+
+	// Parse environment and options
+	flag.Parse()
+	args := flag.Args()
+
+	// Setup IO
+	var err error
+
+	out := os.Stdout
+	eout := os.Stderr
+
+	if outputFName != "" {
+		out, err = os.Create(outputFName)
+		if err != nil {
+			fmt.Fprintf(eout, "%s\n", err)
+			os.Exit(1)
+		}
+		defer out.Close()
+	}
+
+	// Process options
+	if showHelp {
+		fmt.Fprintf(out, "%s\n", fmtHelp(helpText, appName, version, releaseDate, releaseHash))
+		os.Exit(0)
+	}
+	if showLicense {
+		fmt.Fprintf(out, "%s\n", fountain.LicenseText)
+		os.Exit(0)
+	}
+	if showVersion {
+		fmt.Fprintf(out, "%s %s %s\n", appName, version, releaseHash)
+		os.Exit(0)
+	}
+	format = strings.ToLower(format)
+	if format != "gnu" && format != "json" {
+		fmt.Fprintf(eout, "%q is not a supported format\n", format)
+		os.Exit(1)
+	}
+	if inputFName != "" {
+		args = append([]string{inputFName}, args...)
+	}
+
+	// Check the documents
+	diagnostics := []*fileDiagnostic{}
+	if len(args) == 0 {
+		diagnostics, err = lint("<stdin>", os.Stdin)
+		if err != nil {
+			fmt.Fprintf(eout, "%s\n", err)
+			os.Exit(1)
+		}
+	}
+	for _, fname := range args {
+		found, err := lintFile(fname)
+		if err != nil {
+			fmt.Fprintf(eout, "%s\n", err)
+			os.Exit(1)
+		}
+		diagnostics = append(diagnostics, found...)
+	}
+
+	// Report the diagnostics
+	if format == "json" {
+		encoder := json.NewEncoder(out)
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("", "    ")
+		if err := encoder.Encode(diagnostics); err != nil {
+			fmt.Fprintf(eout, "%s\n", err)
+			os.Exit(1)
+		}
+	} else {
+		for _, d := range diagnostics {
+			fmt.Fprintf(out, "%s:%s [%s]\n", d.File, d.Diagnostic, d.Code)
+		}
+	}
+	if len(diagnostics) > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,336 @@
+//
+// fountainoutline writes the outline (sections, scenes and synopses) of a Fountain file.
+//
+//
+// fountain is a package encoding/decoding fountain formatted screenplays.
+//
+// @author R. S. Doiel, <rsdoiel@gmail.com>
+//
+// BSD 2-Clause License
+//
+// Copyright (c) 2019, R. S. Doiel
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+//
+package main
+
+import (
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	// My packages
+	"github.com/rsdoiel/fountain"
+)
+
+var (
+	helpText = `%{app_name}(1) | version {version} {release_hash}
+% R. S. Doiel
+% {release_date}
+
+# NAME
+
+{app_name}
+
+# SYNOPSIS
+
+{app_name} [OPTIONS]
+
+# DESCRIPTION
+
+{app_name} is a command line program that reads an fountain document and writes out its outline, the sections (# Act, ## Sequence, etc.) with the scenes and synopses (= ...) they hold, as indented text, Markdown or OPML.
+
+Sections nest by the number of #, a scene belongs to the section above it and a synopsis to the section or scene it follows. Scenes are identified by scene number or their position in the script.
+
+# OPTIONS
+
+-help
+: display help
+
+-license
+: display license
+
+-version
+: display version
+
+-i
+: read from input file
+
+-o
+: write to output file
+
+-format
+: set the output format, text, markdown or opml (default text). In OPML the synopses are the notes (_note) of their section or scene.
+
+
+# EXAMPLES
+
+List the outline of *screenplay.fountain*.
+
+~~~
+    {app_name} -i screenplay.fountain
+~~~
+
+Write the outline of *screenplay.fountain* as OPML for an outliner.
+
+~~~
+    {app_name} -i screenplay.fountain -format opml -o screenplay.opml
+~~~
+
+Or alternatively
+
+~~~
+    cat screenplay.fountain | {app_name} -format markdown
+~~~
+
+`
+
+	// Standard Options
+	showHelp    bool
+	showLicense bool
+	showVersion bool
+	quiet       bool
+	inputFName  string
+	outputFName string
+
+	// App Option
+	format string
+)
+
+// synopses returns the text of the synopses held by a node
+func synopses(node *fountain.OutlineNode) []string {
+	texts := []string{}
+	for _, child := range node.Children {
+		if child.Type == fountain.SynopsisType {
+			texts = append(texts, child.Text)
+		}
+	}
+	return texts
+}
+
+// writeText writes the outline indented by level using the Fountain
+// markers for sections and synopses
+func writeText(out io.Writer, nodes []*fountain.OutlineNode, level int) {
+	indent := strings.Repeat("  ", level)
+	for _, node := range nodes {
+		switch node.Type {
+		case fountain.SectionType:
+			fmt.Fprintf(out, "%s%s %s\n", indent, strings.Repeat("#", node.Depth), node.Text)
+		case fountain.SceneHeadingType:
+			fmt.Fprintf(out, "%s%s %s\n", indent, node.Number, node.Text)
+		case fountain.SynopsisType:
+			fmt.Fprintf(out, "%s= %s\n", indent, node.Text)
+		}
+		writeText(out, node.Children, level+1)
+	}
+}
+
+// markdownEscape backslash escapes the characters Markdown could read
+// as markup
+func markdownEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`, "<", `\<`, "`", "\\`").Replace(s)
+}
+
+// writeMarkdown writes the sections as headings with their synopses as
+// paragraphs and the scenes as a list with their synopses below them
+func writeMarkdown(out io.Writer, nodes []*fountain.OutlineNode, inList bool) bool {
+	for _, node := range nodes {
+		switch node.Type {
+		case fountain.SectionType:
+			fmt.Fprintf(out, "\n%s %s\n", strings.Repeat("#", min(node.Depth, 6)), markdownEscape(node.Text))
+			for _, text := range synopses(node) {
+				fmt.Fprintf(out, "\n%s\n", markdownEscape(text))
+			}
+			children := []*fountain.OutlineNode{}
+			for _, child := range node.Children {
+				if child.Type != fountain.SynopsisType {
+					children = append(children, child)
+				}
+			}
+			inList = writeMarkdown(out, children, false)
+		case fountain.SceneHeadingType:
+			if !inList {
+				fmt.Fprintln(out, "")
+				inList = true
+			}
+			fmt.Fprintf(out, "- **%s** %s\n", markdownEscape(node.Number), markdownEscape(node.Text))
+			for _, text := range synopses(node) {
+				fmt.Fprintf(out, "    - %s\n", markdownEscape(text))
+			}
+		case fountain.SynopsisType:
+			fmt.Fprintf(out, "\n%s\n", markdownEscape(node.Text))
+			inList = false
+		}
+	}
+	return inList
+}
+
+// opmlOutline is an outline element of an OPML document
+type opmlOutline struct {
+	Text     string         `xml:"text,attr"`
+	Type     string         `xml:"type,attr,omitempty"`
+	Number   string         `xml:"number,attr,omitempty"`
+	Note     string         `xml:"_note,attr,omitempty"`
+	Outlines []*opmlOutline `xml:"outline"`
+}
+
+// opmlDocument is an OPML 2.0 document
+type opmlDocument struct {
+	XMLName  xml.Name       `xml:"opml"`
+	Version  string         `xml:"version,attr"`
+	Title    string         `xml:"head>title,omitempty"`
+	Outlines []*opmlOutline `xml:"body>outline"`
+}
+
+// opmlOutlines converts the outline nodes, the synopses of a section or
+// scene become its note
+func opmlOutlines(nodes []*fountain.OutlineNode, top bool) []*opmlOutline {
+	outlines := []*opmlOutline{}
+	for _, node := range nodes {
+		outline := &opmlOutline{Text: node.Text}
+		switch node.Type {
+		case fountain.SectionType:
+			outline.Type = "section"
+		case fountain.SceneHeadingType:
+			outline.Type = "scene"
+			outline.Number = node.Number
+		case fountain.SynopsisType:
+			if !top {
+				continue
+			}
+			outline.Type = "synopsis"
+		}
+		outline.Note = strings.Join(synopses(node), "\n")
+		outline.Outlines = opmlOutlines(node.Children, false)
+		outlines = append(outlines, outline)
+	}
+	return outlines
+}
+
+// writeOPML writes the outline as an OPML document
+func writeOPML(out io.Writer, outline *fountain.Outline) error {
+	doc := &opmlDocument{Version: "2.0", Title: outline.Title, Outlines: opmlOutlines(outline.Nodes, true)}
+	src, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "%s%s\n", xml.Header, src)
+	return nil
+}
+
+// writeOutline writes the outline in the given format
+func writeOutline(out io.Writer, outline *fountain.Outline, format string) error {
+	switch format {
+	case "text":
+		if outline.Title != "" {
+			fmt.Fprintf(out, "%s\n\n", outline.Title)
+		}
+		writeText(out, outline.Nodes, 0)
+		return nil
+	case "markdown", "md":
+		if outline.Title != "" {
+			fmt.Fprintf(out, "---\ntitle: %q\n---\n", outline.Title)
+		}
+		writeMarkdown(out, outline.Nodes, false)
+		return nil
+	case "opml":
+		return writeOPML(out, outline)
+	}
+	return fmt.Errorf("%q is not a supported format", format)
+}
+
+func main() {
+	appName := path.Base(os.Args[0])
+	// NOTE: These are set when version.go is generated
+	version := fountain.Version
+	releaseDate := fountain.ReleaseDate
+	releaseHash := fountain.ReleaseHash
+	fmtHelp := fountain.FmtHelp
+
+	// Standard Options
+	flag.BoolVar(&showHelp, "help", false, "display help")
+	flag.BoolVar(&showLicense, "license", false, "display license")
+	flag.BoolVar(&showVersion, "version", false, "display version")
+	flag.BoolVar(&quiet, "quiet", false, "suppress error messages")
+	flag.StringVar(&inputFName, "i", "", "set the input filename")
+	flag.StringVar(&outputFName, "o", "", "set the output filename")
+
+	// App Option
+	flag.StringVar(&format, "format", "text", "set the output format, text, markdown or opml")
+
+	// Parse environment and options
+	flag.Parse()
+
+	// Setup IO
+	var err error
+
+	in := os.Stdin
+	out := os.Stdout
+	eout := os.Stderr
+
+	if inputFName != "" {
+		in, err = os.Open(inputFName)
+		if err != nil {
+			fmt.Fprintf(eout, "%s\n", err)
+			os.Exit(1)
+		}
+		defer in.Close()
+	}
+	if outputFName != "" {
+		out, err = os.Create(outputFName)
+		if err != nil {
+			fmt.Fprintf(eout, "%s\n", err)
+			os.Exit(1)
+		}
+		defer out.Close()
+	}
+
+	// Process options
+	if showHelp {
+		fmt.Fprintf(out, "%s\n", fmtHelp(helpText, appName, version, releaseDate, releaseHash))
+		os.Exit(0)
+	}
+	if showLicense {
+		fmt.Fprintf(out, "%s\n", fountain.LicenseText)
+		os.Exit(0)
+	}
+	if showVersion {
+		fmt.Fprintf(out, "%s %s %s\n", appName, version, releaseHash)
+		os.Exit(0)
+	}
+
+	// Parse input and write the outline
+	screenplay, err := fountain.NewDecoder(in).Decode()
+	if err != nil {
+		fmt.Fprintf(eout, "%s\n", err)
+		os.Exit(1)
+	}
+	if err := writeOutline(out, screenplay.Outline(), strings.ToLower(format)); err != nil {
+		fmt.Fprintf(eout, "%s\n", err)
+		os.Exit(1)
+	}
+}
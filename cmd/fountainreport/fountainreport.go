@@ -0,0 +1,365 @@
+//
+// fountainreport generates reports (e.g. character statistics) from a Fountain file.
+//
+//
+// fountain is a package encoding/decoding fountain formatted screenplays.
+//
+// @author R. S. Doiel, <rsdoiel@gmail.com>
+//
+// BSD 2-Clause License
+//
+// Copyright (c) 2019, R. S. Doiel
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+//
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"text/tabwriter"
+
+	// My packages
+	"github.com/rsdoiel/fountain"
+)
+
+var (
+	helpText = `%{app_name}(1) | version {version} {release_hash}
+% R. S. Doiel
+% {release_date}
+
+# NAME
+
+{app_name}
+
+# SYNOPSIS
+
+{app_name} [OPTIONS] REPORT
+
+# DESCRIPTION
+
+{app_name} is a command line program that reads an fountain document and writes out a report as text, CSV or JSON.
+
+# REPORTS
+
+characters
+: for each character the number of dialogue blocks, the words spoken, the scenes they appear in, their first and last appearance and the extensions used (e.g. V.O., O.S., CONT'D). Scenes are identified by scene number or their position in the script.
+
+scenes
+: the scene list, for each scene the setting (INT, EXT or I/E), location, sub-location, time of day and the characters who speak. The JSON output includes the location totals and the day and night counts.
+
+locations
+: for each location the number of scenes set there and how many of them are day or night scenes.
+
+pages
+: the page count, where each page starts and the length of each scene in eighths of a page (e.g. 3/8, 1 2/8) as used in scheduling. The script is laid out in the standard screenplay format so the lengths are close to those of Final Draft. The CSV output lists the scenes, the JSON output includes the pages.
+
+# OPTIONS
+
+-help
+: display help
+
+-license
+: display license
+
+-version
+: display version
+
+-i
+: read from input file
+
+-o
+: write to output file
+
+-format
+: set the output format, text, csv or json (default text)
+
+-paper
+: set the paper size used to lay out the pages, letter or a4 (default letter)
+
+
+# EXAMPLES
+
+List the characters in *screenplay.fountain* as CSV.
+
+~~~
+    {app_name} -i screenplay.fountain -format csv characters
+~~~
+
+Write the scene breakdown of *screenplay.fountain* as JSON.
+
+~~~
+    {app_name} -i screenplay.fountain -format json scenes
+~~~
+
+List the scene lengths in eighths of a page of *screenplay.fountain* laid out on A4 paper.
+
+~~~
+    {app_name} -i screenplay.fountain -paper a4 pages
+~~~
+
+Or alternatively
+
+~~~
+    cat screenplay.fountain | {app_name} -format csv characters
+~~~
+
+`
+
+	// Standard Options
+	showHelp    bool
+	showLicense bool
+	showVersion bool
+	quiet       bool
+	inputFName  string
+	outputFName string
+
+	// App Option
+	format    string
+	paperSize string
+)
+
+// writeJSON writes the report as indented JSON
+func writeJSON(out io.Writer, report interface{}) error {
+	src, err := json.MarshalIndent(report, "", "    ")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "%s\n", src)
+	return nil
+}
+
+// characterReport writes the character statistics in the given format
+func characterReport(out io.Writer, screenplay *fountain.Fountain, format string) error {
+	stats := screenplay.CharacterStats()
+	switch format {
+	case "json":
+		return writeJSON(out, stats)
+	case "csv":
+		w := csv.NewWriter(out)
+		w.Write([]string{"name", "dialogue_blocks", "words", "scene_count", "scenes", "first_scene", "last_scene", "first_line", "last_line", "extensions"})
+		for _, c := range stats {
+			w.Write([]string{c.Name, fmt.Sprintf("%d", c.DialogueBlocks), fmt.Sprintf("%d", c.Words),
+				fmt.Sprintf("%d", len(c.Scenes)), strings.Join(c.Scenes, " "), c.FirstScene, c.LastScene,
+				fmt.Sprintf("%d", c.FirstLine), fmt.Sprintf("%d", c.LastLine), strings.Join(c.Extensions, " ")})
+		}
+		w.Flush()
+		return w.Error()
+	case "text":
+		w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tBLOCKS\tWORDS\tSCENES\tFIRST\tLAST\tEXTENSIONS")
+		for _, c := range stats {
+			fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%s\t%s\t%s\n", c.Name, c.DialogueBlocks, c.Words, len(c.Scenes), c.FirstScene, c.LastScene, strings.Join(c.Extensions, ", "))
+		}
+		return w.Flush()
+	}
+	return fmt.Errorf("%q is not a supported format", format)
+}
+
+// sceneReport writes the scene list in the given format
+func sceneReport(out io.Writer, screenplay *fountain.Fountain, format string) error {
+	breakdown := screenplay.SceneBreakdown()
+	switch format {
+	case "json":
+		return writeJSON(out, breakdown)
+	case "csv":
+		w := csv.NewWriter(out)
+		w.Write([]string{"scene", "line", "setting", "location", "sub_location", "time_of_day", "characters"})
+		for _, scene := range breakdown.Scenes {
+			w.Write([]string{scene.Number, fmt.Sprintf("%d", scene.Line), scene.Setting, scene.Location,
+				scene.SubLocation, scene.TimeOfDay, strings.Join(scene.Characters, ", ")})
+		}
+		w.Flush()
+		return w.Error()
+	case "text":
+		w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "SCENE\tSETTING\tLOCATION\tSUB-LOCATION\tTIME\tCHARACTERS")
+		for _, scene := range breakdown.Scenes {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", scene.Number, scene.Setting, scene.Location, scene.SubLocation, scene.TimeOfDay, strings.Join(scene.Characters, ", "))
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "\n%d scenes, %d day, %d night, %d other\n", len(breakdown.Scenes), breakdown.Day, breakdown.Night, breakdown.Other)
+		return nil
+	}
+	return fmt.Errorf("%q is not a supported format", format)
+}
+
+// locationReport writes the location totals in the given format
+func locationReport(out io.Writer, screenplay *fountain.Fountain, format string) error {
+	locations := screenplay.SceneBreakdown().Locations
+	switch format {
+	case "json":
+		return writeJSON(out, locations)
+	case "csv":
+		w := csv.NewWriter(out)
+		w.Write([]string{"location", "scenes", "day", "night"})
+		for _, l := range locations {
+			w.Write([]string{l.Location, fmt.Sprintf("%d", l.Scenes), fmt.Sprintf("%d", l.Day), fmt.Sprintf("%d", l.Night)})
+		}
+		w.Flush()
+		return w.Error()
+	case "text":
+		w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "LOCATION\tSCENES\tDAY\tNIGHT")
+		for _, l := range locations {
+			fmt.Fprintf(w, "%s\t%d\t%d\t%d\n", l.Location, l.Scenes, l.Day, l.Night)
+		}
+		return w.Flush()
+	}
+	return fmt.Errorf("%q is not a supported format", format)
+}
+
+// pageReport writes the page count, page breaks and scene lengths in
+// the given format
+func pageReport(out io.Writer, screenplay *fountain.Fountain, format string, paper *fountain.PaperSize) error {
+	pagination := screenplay.Paginate(paper)
+	switch format {
+	case "json":
+		return writeJSON(out, pagination)
+	case "csv":
+		w := csv.NewWriter(out)
+		w.Write([]string{"scene", "heading", "page", "lines", "eighths", "length"})
+		for _, scene := range pagination.Scenes {
+			w.Write([]string{scene.Number, scene.Heading, fmt.Sprintf("%d", scene.Page),
+				fmt.Sprintf("%d", scene.Lines), fmt.Sprintf("%d", scene.Eighths), scene.Length})
+		}
+		w.Flush()
+		return w.Error()
+	case "text":
+		fmt.Fprintf(out, "%d pages (%s, %d lines per page)\n\n", pagination.PageCount, pagination.PaperSize, pagination.LinesPerPage)
+		w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "SCENE\tPAGE\tLENGTH\tHEADING")
+		for _, scene := range pagination.Scenes {
+			fmt.Fprintf(w, "%s\t%d\t%s\t%s\n", scene.Number, scene.Page, scene.Length, scene.Heading)
+		}
+		fmt.Fprintln(w, "")
+		fmt.Fprintln(w, "PAGE\tLINE\tSCENE")
+		for _, page := range pagination.Pages {
+			fmt.Fprintf(w, "%d\t%d\t%s\n", page.Number, page.Line, page.Scene)
+		}
+		return w.Flush()
+	}
+	return fmt.Errorf("%q is not a supported format", format)
+}
+
+func main() {
+	appName := path.Base(os.Args[0])
+	// NOTE: These are set when version.go is generated
+	version := fountain.Version
+	releaseDate := fountain.ReleaseDate
+	releaseHash := fountain.ReleaseHash
+	fmtHelp := fountain.FmtHelp
+
+	// Standard Options
+	flag.BoolVar(&showHelp, "help", false, "display help")
+	flag.BoolVar(&showLicense, "license", false, "display license")
+	flag.BoolVar(&showVersion, "version", false, "display version")
+	flag.BoolVar(&quiet, "quiet", false, "suppress error messages")
+	flag.StringVar(&inputFName, "i", "", "set the input filename")
+	flag.StringVar(&outputFName, "o", "", "set the output filename")
+
+	// App Option
+	flag.StringVar(&format, "format", "text", "set the output format, text, csv or json")
+	flag.StringVar(&paperSize, "paper", "letter", "set the paper size, letter or a4")
+
+	// Parse environment and options
+	flag.Parse()
+	args := flag.Args()
+
+	// Setup IO
+	var err error
+
+	in := os.Stdin
+	out := os.Stdout
+	eout := os.Stderr
+
+	if inputFName != "" {
+		in, err = os.Open(inputFName)
+		if err != nil {
+			fmt.Fprintf(eout, "%s\n", err)
+			os.Exit(1)
+		}
+		defer in.Close()
+	}
+	if outputFName != "" {
+		out, err = os.Create(outputFName)
+		if err != nil {
+			fmt.Fprintf(eout, "%s\n", err)
+			os.Exit(1)
+		}
+		defer out.Close()
+	}
+
+	// Process options
+	if showHelp {
+		fmt.Fprintf(out, "%s\n", fmtHelp(helpText, appName, version, releaseDate, releaseHash))
+		os.Exit(0)
+	}
+	if showLicense {
+		fmt.Fprintf(out, "%s\n", fountain.LicenseText)
+		os.Exit(0)
+	}
+	if showVersion {
+		fmt.Fprintf(out, "%s %s %s\n", appName, version, releaseHash)
+		os.Exit(0)
+	}
+	if len(args) != 1 {
+		fmt.Fprintf(eout, "USAGE: %s [OPTIONS] REPORT, see %s -help\n", appName, appName)
+		os.Exit(1)
+	}
+
+	// Parse input and write the report
+	screenplay, err := fountain.NewDecoder(in).Decode()
+	if err != nil {
+		fmt.Fprintf(eout, "%s\n", err)
+		os.Exit(1)
+	}
+	switch args[0] {
+	case "characters":
+		err = characterReport(out, screenplay, strings.ToLower(format))
+	case "scenes":
+		err = sceneReport(out, screenplay, strings.ToLower(format))
+	case "locations":
+		err = locationReport(out, screenplay, strings.ToLower(format))
+	case "pages":
+		paper := fountain.LetterPaper
+		if strings.ToLower(paperSize) == "a4" {
+			paper = fountain.A4Paper
+		}
+		err = pageReport(out, screenplay, strings.ToLower(format), paper)
+	default:
+		err = fmt.Errorf("%q is not a supported report", args[0])
+	}
+	if err != nil {
+		fmt.Fprintf(eout, "%s\n", err)
+		os.Exit(1)
+	}
+}
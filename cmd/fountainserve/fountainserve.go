@@ -0,0 +1,189 @@
+//
+// fountainserve serves Fountain files as HTML and reloads the browser when they change.
+//
+//
+// fountain is a package encoding/decoding fountain formatted screenplays.
+//
+// @author R. S. Doiel, <rsdoiel@gmail.com>
+//
+// BSD 2-Clause License
+//
+// Copyright (c) 2019, R. S. Doiel
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+//
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"time"
+
+	// My packages
+	"github.com/rsdoiel/fountain"
+)
+
+var (
+	helpText = `%{app_name}(1) | version {version} {release_hash}
+% R. S. Doiel
+% {release_date}
+
+# NAME
+
+{app_name}
+
+# SYNOPSIS
+
+{app_name} [OPTIONS] [FILE|DIRECTORY ...]
+
+# DESCRIPTION
+
+{app_name} is a live preview web server for fountain documents. It
+serves each fountain file named (or found in the directories named,
+the current directory by default) as HTML and the browser reloads the
+page when the file is saved. Open it next to your editor to see the
+formatted screenplay while you write.
+
+The index page lists the scripts being served. New scripts saved in
+a directory being watched show up on the index page.
+
+The CSS is read from the file named with -css, otherwise from
+*fountain.css* or *css/fountain.css* in the current directory if
+found, otherwise the default fountain CSS is used.
+
+# OPTIONS
+
+-help
+: display help
+
+-license
+: display license
+
+-version
+: display version
+
+-addr
+: the address to listen on (default localhost:8000)
+
+-css
+: use a custom CSS file
+
+-width
+: set the width for the text
+
+-interval
+: how often to check the files for changes (default 500ms)
+
+
+# EXAMPLES
+
+Preview the screenplays in the current directory at
+http://localhost:8000.
+
+~~~
+    {app_name}
+~~~
+
+Preview *screenplay.fountain* on port 8080.
+
+~~~
+    {app_name} -addr localhost:8080 screenplay.fountain
+~~~
+
+`
+
+	// Standard Options
+	showHelp    bool
+	showLicense bool
+	showVersion bool
+
+	// App Option
+	addr       string
+	includeCSS string
+	width      int
+	interval   time.Duration
+)
+
+func main() {
+	appName := path.Base(os.Args[0])
+	// NOTE: These are set when version.go is generated
+	version := fountain.Version
+	releaseDate := fountain.ReleaseDate
+	releaseHash := fountain.ReleaseHash
+	fmtHelp := fountain.FmtHelp
+
+	// Standard Options
+	flag.BoolVar(&showHelp, "help", false, "display help")
+	flag.BoolVar(&showLicense, "license", false, "display license")
+	flag.BoolVar(&showVersion, "version", false, "display version")
+
+	// App Option
+	flag.StringVar(&addr, "addr", "localhost:8000", "the address to listen on")
+	flag.StringVar(&includeCSS, "css", "", "use a custom CSS file")
+	flag.IntVar(&width, "width", 65, "set the width for the text")
+	flag.DurationVar(&interval, "interval", 500*time.Millisecond, "how often to check the files for changes")
+
+	// Parse environment and options
+	flag.Parse()
+	args := flag.Args()
+
+	out := os.Stdout
+	eout := os.Stderr
+
+	// Process options
+	if showHelp {
+		fmt.Fprintf(out, "%s\n", fmtHelp(helpText, appName, version, releaseDate, releaseHash))
+		os.Exit(0)
+	}
+	if showLicense {
+		fmt.Fprintf(out, "%s\n", fountain.LicenseText)
+		os.Exit(0)
+	}
+	if showVersion {
+		fmt.Fprintf(out, "%s %s %s\n", appName, version, releaseHash)
+		os.Exit(0)
+	}
+	for _, arg := range args {
+		if _, err := os.Stat(arg); err != nil {
+			fmt.Fprintf(eout, "%s\n", err)
+			os.Exit(1)
+		}
+	}
+	if interval <= 0 {
+		fmt.Fprintf(eout, "-interval must be greater than zero\n")
+		os.Exit(1)
+	}
+
+	// Watch the scripts and serve them until interrupted
+	s := newServer(args, includeCSS, width)
+	done := make(chan bool)
+	go s.watch(interval, done)
+	fmt.Fprintf(out, "%s serving %d script(s) at http://%s\n", appName, len(s.scripts()), addr)
+	if err := http.ListenAndServe(addr, s.handler()); err != nil {
+		fmt.Fprintf(eout, "%s\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,289 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	// My packages
+	"github.com/rsdoiel/fountain"
+)
+
+// reloadScript asks the server for change events and reloads the page
+// when the script shown changes (any change on the index page).
+const reloadScript = `<script>
+(function () {
+    var name = %s;
+    var source = new EventSource("/events");
+    source.onmessage = function (event) {
+        if (name === "" || event.data === name) {
+            window.location.reload();
+        }
+    };
+})();
+</script>
+`
+
+// server watches the fountain files and serves them as HTML
+type server struct {
+	paths []string
+	css   string
+	opt   *fountain.Options
+
+	mu       sync.Mutex
+	modTimes map[string]time.Time
+	clients  map[chan string]bool
+}
+
+func newServer(paths []string, css string, width int) *server {
+	opt := fountain.DefaultOptions()
+	opt.AsHTMLPage = false
+	opt.InlineCSS = false
+	opt.LinkCSS = false
+	opt.MaxWidth = width
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	return &server{
+		paths:    paths,
+		css:      css,
+		opt:      opt,
+		modTimes: map[string]time.Time{},
+		clients:  map[chan string]bool{},
+	}
+}
+
+// scriptName returns the URL path of a script, the filename relative to
+// the working directory or the base name if outside of it.
+func scriptName(fname string) string {
+	if cwd, err := os.Getwd(); err == nil {
+		if abs, err := filepath.Abs(fname); err == nil {
+			if rel, err := filepath.Rel(cwd, abs); err == nil && !strings.HasPrefix(rel, "..") {
+				return filepath.ToSlash(rel)
+			}
+		}
+	}
+	return filepath.Base(fname)
+}
+
+// scripts returns the fountain files being served keyed by their URL
+// path. Directories are read each time so new scripts are picked up.
+func (s *server) scripts() map[string]string {
+	scripts := map[string]string{}
+	for _, p := range s.paths {
+		info, err := os.Stat(p)
+		if err != nil {
+			continue
+		}
+		if !info.IsDir() {
+			scripts[scriptName(p)] = p
+			continue
+		}
+		entries, err := os.ReadDir(p)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() && strings.HasSuffix(strings.ToLower(entry.Name()), ".fountain") {
+				fname := filepath.Join(p, entry.Name())
+				scripts[scriptName(fname)] = fname
+			}
+		}
+	}
+	return scripts
+}
+
+// names returns the sorted URL paths of the scripts
+func names(scripts map[string]string) []string {
+	keys := []string{}
+	for key := range scripts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// poll compares the modification times of the scripts with those seen
+// last time and tells the clients about new, changed and removed scripts.
+func (s *server) poll() []string {
+	changed := []string{}
+	seen := map[string]bool{}
+	scripts := s.scripts()
+	s.mu.Lock()
+	for _, name := range names(scripts) {
+		seen[name] = true
+		info, err := os.Stat(scripts[name])
+		if err != nil {
+			continue
+		}
+		if modTime, ok := s.modTimes[name]; !ok || !modTime.Equal(info.ModTime()) {
+			s.modTimes[name] = info.ModTime()
+			changed = append(changed, name)
+		}
+	}
+	for name := range s.modTimes {
+		if !seen[name] {
+			delete(s.modTimes, name)
+			changed = append(changed, name)
+		}
+	}
+	s.mu.Unlock()
+	for _, name := range changed {
+		s.broadcast(name)
+	}
+	return changed
+}
+
+// watch polls the scripts every interval until done is closed
+func (s *server) watch(interval time.Duration, done <-chan bool) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	s.poll()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			s.poll()
+		}
+	}
+}
+
+// broadcast sends the name of a changed script to each client, a client
+// that is not keeping up misses the event rather than blocking the others.
+func (s *server) broadcast(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for client := range s.clients {
+		select {
+		case client <- name:
+		default:
+		}
+	}
+}
+
+func (s *server) subscribe() chan string {
+	client := make(chan string, 16)
+	s.mu.Lock()
+	s.clients[client] = true
+	s.mu.Unlock()
+	return client
+}
+
+func (s *server) unsubscribe(client chan string) {
+	s.mu.Lock()
+	delete(s.clients, client)
+	s.mu.Unlock()
+}
+
+// getCSS returns the CSS file named with -css, otherwise fountain.css
+// or css/fountain.css if found, falling back to fountain.SourceCSS.
+func (s *server) getCSS() string {
+	candidates := []string{"fountain.css", path.Join("css", "fountain.css")}
+	if s.css != "" {
+		candidates = []string{s.css}
+	}
+	for _, fname := range candidates {
+		if src, err := os.ReadFile(fname); err == nil {
+			return string(src)
+		}
+	}
+	return fountain.SourceCSS
+}
+
+// writePage writes an HTML page with the CSS and the reload script
+func (s *server) writePage(w http.ResponseWriter, name string, title string, body string) {
+	quoted, _ := json.Marshal(name)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	fmt.Fprintf(w, `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>%s</title>
+<style>
+%s
+</style>
+</head>
+<body>
+%s
+`, html.EscapeString(title), s.getCSS(), body)
+	fmt.Fprintf(w, reloadScript, quoted)
+	fmt.Fprintf(w, "</body>\n</html>\n")
+}
+
+// handleIndex lists the scripts, other paths are handed to handleScript
+func (s *server) handleIndex(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		s.handleScript(w, r)
+		return
+	}
+	scripts := s.scripts()
+	out := []string{"<h1>Scripts</h1>", "<ul>"}
+	for _, name := range names(scripts) {
+		u := &url.URL{Path: "/" + name}
+		out = append(out, fmt.Sprintf(`<li><a href="%s">%s</a></li>`, html.EscapeString(u.EscapedPath()), html.EscapeString(name)))
+	}
+	out = append(out, "</ul>")
+	s.writePage(w, "", "Scripts", strings.Join(out, "\n"))
+}
+
+// handleScript renders a script as HTML. Only the scripts being watched
+// are served. Parse errors are shown in the page so it recovers once
+// the script is fixed.
+func (s *server) handleScript(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/")
+	fname, ok := s.scripts()[name]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	nav := fmt.Sprintf(`<nav><a href="/">Scripts</a> / %s</nav>`, html.EscapeString(name))
+	doc, err := fountain.ParseFile(fname)
+	if err != nil {
+		s.writePage(w, name, name, fmt.Sprintf("%s\n<pre class=%q>%s</pre>", nav, "error", html.EscapeString(err.Error())))
+		return
+	}
+	s.writePage(w, name, name, nav+"\n"+doc.RenderHTML(s.opt))
+}
+
+// handleEvents streams the names of changed scripts as server-sent events
+func (s *server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	client := s.subscribe()
+	defer s.unsubscribe(client)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	fmt.Fprintf(w, ": watching\n\n")
+	flusher.Flush()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case name := <-client:
+			fmt.Fprintf(w, "data: %s\n\n", name)
+			flusher.Flush()
+		}
+	}
+}
+
+// handler returns the routes served
+func (s *server) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/events", s.handleEvents)
+	mux.HandleFunc("/", s.handleIndex)
+	return mux
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func get(t *testing.T, u string) (int, string) {
+	res, err := http.Get(u)
+	if err != nil {
+		t.Fatalf("GET %s, %s", u, err)
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatalf("reading %s, %s", u, err)
+	}
+	return res.StatusCode, string(body)
+}
+
+func TestServer(t *testing.T) {
+	dir := t.TempDir()
+	fname := filepath.Join(dir, "kitchen.fountain")
+	if err := os.WriteFile(fname, []byte("INT. KITCHEN - DAY\n\nBOB\nHi.\n"), 0664); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not a script"), 0664); err != nil {
+		t.Fatal(err)
+	}
+	s := newServer([]string{dir}, "", 65)
+	if changed := s.poll(); len(changed) != 1 {
+		t.Fatalf("expected one script, got %v", changed)
+	}
+	ts := httptest.NewServer(s.handler())
+	defer ts.Close()
+
+	// Scripts outside the working directory are served by base name
+	name := "kitchen.fountain"
+	status, body := get(t, ts.URL+"/")
+	if status != http.StatusOK || !strings.Contains(body, `<a href="/kitchen.fountain">kitchen.fountain</a>`) || strings.Contains(body, "notes.txt") {
+		t.Errorf("unexpected index %d\n%s", status, body)
+	}
+	status, body = get(t, ts.URL+"/"+name)
+	for _, expected := range []string{`<div class="scene-heading">INT. KITCHEN - DAY</div>`, `<div class="character">BOB</div>`, ".scene-heading", `new EventSource("/events")`} {
+		if status != http.StatusOK || !strings.Contains(body, expected) {
+			t.Errorf("expected %q in %d\n%s", expected, status, body)
+		}
+	}
+	if status, _ = get(t, ts.URL+"/notes.txt"); status != http.StatusNotFound {
+		t.Errorf("expected only scripts to be served, got %d", status)
+	}
+
+	res, err := http.Get(ts.URL + "/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if ct := res.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("expected an event stream, got %q", ct)
+	}
+	events := bufio.NewReader(res.Body)
+	if line, _ := events.ReadString('\n'); line != ": watching\n" {
+		t.Fatalf("expected the stream to start, got %q", line)
+	}
+	events.ReadString('\n')
+
+	// Change the script, a new mod time is reported to the browser
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(fname, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if changed := s.poll(); len(changed) != 1 || changed[0] != name {
+		t.Errorf("expected %q to change, got %v", name, changed)
+	}
+	if line, _ := events.ReadString('\n'); line != "data: "+name+"\n" {
+		t.Errorf("expected a change event for %q, got %q", name, line)
+	}
+	if changed := s.poll(); len(changed) != 0 {
+		t.Errorf("expected no changes, got %v", changed)
+	}
+}
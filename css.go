@@ -130,6 +130,48 @@ section.script {
     text-decoration: none;
 }
 
+.credit,
+.source {
+    text-align: center;
+    padding-left: 33%;
+    padding-right: 33%;
+    margin-top: 0;
+    margin-bottom: 1em;
+}
+
+/* The title page keys are centered, bottom left and bottom right */
+.title-page-center {
+    margin-bottom: 6em;
+}
+
+.title-page-left {
+    float: left;
+    width: 50%;
+}
+
+.title-page-right {
+    float: right;
+    text-align: right;
+    padding-right: 1em;
+}
+
+.title-page-right .draft-date,
+.title-page-right .notes {
+    padding: 0;
+    margin: 0;
+    text-align: right;
+}
+
+.title-page-left .general-text {
+    margin: 0;
+}
+
+section.title-page:after {
+    content: "";
+    display: block;
+    clear: both;
+}
+
 .script {
     padding-top: 2em;
     padding-left: 0;
@@ -158,6 +200,24 @@ section.script {
     display: block;
 }
 
+/* Scene numbers are shown in both margins */
+.scene-heading {
+    position: relative;
+}
+
+.scene-number-left,
+.scene-number-right {
+    position: absolute;
+}
+
+.scene-number-left {
+    left: -3em;
+}
+
+.scene-number-right {
+    right: 0;
+}
+
 .action {
     padding-right: 5% !important;
     font-size: 12px !important;
@@ -183,6 +243,33 @@ section.script {
     padding-bottom: 0 !important;
 }
 
+/* Dual dialogue, two speeches side by side */
+.dual-dialogue {
+    display: flex;
+    flex-direction: row;
+    width: 100%;
+}
+
+.dual-dialogue-left,
+.dual-dialogue-right {
+    flex: 1;
+    width: 50%;
+}
+
+.dual-dialogue .character {
+    padding-left: 30% !important;
+}
+
+.dual-dialogue .dialogue {
+    padding-left: 5% !important;
+    padding-right: 5% !important;
+}
+
+.dual-dialogue .parenthetical {
+    padding-left: 15% !important;
+    padding-right: 10% !important;
+}
+
 .left-align {
     float: left;
     padding-left: 2em;
@@ -205,13 +292,20 @@ section.script {
     display: none;
     height: 0;
 }
+
+/* Notes and boneyard are only included when asked for */
+.note,
+.boneyard {
+    color: #808080;
+    font-style: italic;
+}
 `
 )
 
 // getCSS() checks to see if there is any custom CSS filenamed fountain.css
 // in the current work directory or in the CSS folder and gets that
 // otherwise it'll fall back the value in SourceCSS.
-func getCSS() (string, error) {
+func getCSS(css string) (string, error) {
 	var (
 		src []byte
 		err error
@@ -219,29 +313,26 @@ func getCSS() (string, error) {
 	// NOTE: If CSS value not set then look for fountain.css in
 	// current work directory and in css/fountain.css before falling
 	// back to the default SourceCSS value.
-	if CSS == "" {
+	if css == "" {
 		// 1. Find where we've put any custom CSS
 		if _, err = os.Stat("fountain.css"); os.IsNotExist(err) == false {
-			CSS = "fountain.css"
+			css = "fountain.css"
 		} else if _, err = os.Stat(path.Join("css", "fountain.css")); os.IsNotExist(err) == false {
-			CSS = path.Join("css", "fountain.css")
+			css = path.Join("css", "fountain.css")
 		}
 	}
-	src, err = ioutil.ReadFile(CSS)
+	src, err = ioutil.ReadFile(css)
 	if err != nil {
 		return "", err
-	} else {
-		// Replace default CSS with requested CSS
-		SourceCSS = fmt.Sprintf("%s", src)
 	}
 	// 2. Otherwise provide default
-	return createElement("style", []string{}, SourceCSS), nil
+	return createElement("style", []string{}, fmt.Sprintf("%s", src)), nil
 }
 
-func getCSSLink() (string, error) {
+func getCSSLink(css string) (string, error) {
 	var err error
-	if strings.Contains(CSS, "://") == false {
-		_, err = os.Stat(CSS)
+	if strings.Contains(css, "://") == false {
+		_, err = os.Stat(css)
 	}
-	return fmt.Sprintf("<link rel=%q href=%q>\n", "stylesheet", CSS), err
+	return fmt.Sprintf("<link rel=%q href=%q>\n", "stylesheet", css), err
 }
@@ -0,0 +1,351 @@
+// Package fountain is a Golang package supporting Fountain screenplay markup.
+//
+// decoder.go implements a streaming parser, elements are returned as they
+// are classified instead of after the whole screenplay is read.
+package fountain
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// Decoder reads a Fountain screenplay from an io.Reader returning
+// elements as they are classified. Title page elements are returned
+// first followed by the script elements.
+//
+//	decoder := fountain.NewDecoder(os.Stdin)
+//	for {
+//		element, err := decoder.Next()
+//		if err == io.EOF {
+//			break
+//		}
+//		...
+//	}
+type Decoder struct {
+	scanner *bufio.Scanner
+
+	// line tracking for source positions
+	lineLength int
+	lineNo     int
+	nextOffset int
+
+	// newline is the line ending of the first line, ending the one of
+	// the line being read and finalNewline is false if the last line
+	// has no line ending
+	newline      string
+	ending       string
+	finalNewline bool
+
+	prevType         int
+	foundEndOfScript bool
+	// open is the marker closing a note ("]]") or boneyard ("*/") left
+	// open at the end of the last line
+	open string
+
+	// current is the element being assembled from source lines
+	current *Element
+	// lookahead holds classified elements waiting on the character
+	// fix-up, grouping holds elements that may become dual dialogue.
+	lookahead []*Element
+	grouping  []*Element
+	ready     []*Element
+
+	// prevElementType is the last type released by the character fix-up
+	prevElementType int
+	fixupDone       bool
+
+	eof  bool
+	done bool
+	err  error
+}
+
+// NewDecoder returns a new decoder that reads from r
+func NewDecoder(r io.Reader) *Decoder {
+	d := new(Decoder)
+	d.scanner = bufio.NewScanner(r)
+	// NOTE: ScanLines drops the line ending, lineLength keeps track
+	// of the bytes consumed so we can record the source offsets.
+	d.scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		advance, token, err := bufio.ScanLines(data, atEOF)
+		if token != nil {
+			d.lineLength = advance
+			// e.g. "\r\n", a line may end differently than the first
+			d.ending = string(data[len(token):advance])
+			if d.newline == "" {
+				d.newline = d.ending
+			}
+			d.finalNewline = d.ending != ""
+		}
+		return advance, token, err
+	})
+	d.prevType = TitlePageType
+	d.prevElementType = TitlePageType
+	return d
+}
+
+// Next returns the next element. It returns io.EOF when there are
+// no more elements.
+func (d *Decoder) Next() (*Element, error) {
+	for len(d.ready) == 0 {
+		if d.done {
+			if d.err != nil {
+				return nil, d.err
+			}
+			return nil, io.EOF
+		}
+		if d.scanner.Scan() {
+			d.readLine(d.scanner.Text())
+		} else {
+			d.err = d.scanner.Err()
+			d.eof = true
+			d.finishElement()
+		}
+		d.settle()
+		if d.eof && len(d.lookahead) == 0 && len(d.grouping) == 0 {
+			d.done = true
+		}
+	}
+	element := d.ready[0]
+	d.ready = d.ready[1:]
+	return element, nil
+}
+
+// Decode reads the remaining elements and returns them as a Fountain
+// document.
+func (d *Decoder) Decode() (*Fountain, error) {
+	document := new(Fountain)
+	for {
+		element, err := d.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return document, err
+		}
+		if element.Type == TitlePageType {
+			document.TitlePage = append(document.TitlePage, element)
+		} else {
+			document.Elements = append(document.Elements, element)
+		}
+	}
+	document.newline = d.newline
+	document.noFinalNewline = d.lineNo > 0 && !d.finalNewline
+	return document, nil
+}
+
+// finishElement moves the current element into the look ahead queue
+func (d *Decoder) finishElement() {
+	if d.current != nil {
+		if d.current.Type == TitlePageType {
+			d.release(d.current)
+		} else {
+			d.lookahead = append(d.lookahead, d.current)
+		}
+		d.current = nil
+	}
+}
+
+// newElement starts a new current element from a source line
+func (d *Decoder) newElement(elemType int, name string, content string, line string, offset int) {
+	d.finishElement()
+	element := new(Element)
+	element.Type = elemType
+	element.Name = name
+	element.Content = content
+	element.Source = line
+	element.ending = d.ending
+	element.setStart(d.lineNo, offset)
+	element.setEnd(d.lineNo, offset, line)
+	d.current = element
+}
+
+// appendLine adds a source line to the current element
+func (d *Decoder) appendLine(content string, line string, offset int) {
+	d.current.Content = d.current.Content + "\n" + content
+	d.current.Source = d.current.Source + d.current.ending + line
+	d.current.ending = d.ending
+	d.current.setEnd(d.lineNo, offset, line)
+}
+
+// readLine classifies a line of source and adds it to the current
+// element or starts a new one.
+func (d *Decoder) readLine(line string) {
+	d.lineNo++
+	offset := d.nextOffset
+	d.nextOffset += d.lineLength
+	if d.foundEndOfScript {
+		d.newElement(GeneralTextType, typeName(GeneralTextType), line, line, offset)
+		return
+	}
+	if d.open != "" && d.current != nil {
+		if d.open == "]]" && line == "" {
+			// An empty line ends a note left open, a line with
+			// two spaces keeps it going
+			d.open = ""
+		} else {
+			// The line continues the note or boneyard
+			_, d.open = visibleText(line, d.open)
+			d.appendLine(line, line, offset)
+			return
+		}
+	}
+	if d.lineNo == 1 && !isTitlePageKey(line) {
+		// The optional title page is always the first thing in a
+		// script, without a key on the first line there isn't one.
+		d.prevType = EmptyType
+		d.prevElementType = EmptyType
+	}
+	// Notes and boneyard are left out when classifying the line
+	visible, open := visibleText(line, "")
+	currentType := getLineType(visible, d.prevType)
+	if strings.TrimSpace(visible) == "" && strings.TrimSpace(line) != "" {
+		// The line only holds notes or boneyard
+		currentType = annotationType(line)
+	}
+	if currentType != TitlePageType {
+		d.open = open
+	}
+	switch currentType {
+	case TitlePageType:
+		// An unindented "Key:" starts a new key, other lines
+		// continue the value above (e.g. an address)
+		if isTitlePageKey(line) {
+			parts := strings.SplitN(line, ":", 2)
+			d.newElement(TitlePageType, parts[0], parts[1], line, offset)
+		} else {
+			d.appendLine(line, line, offset)
+		}
+	default:
+		// If we haven't changed types we don't need to create
+		// a new element. Each section line is its own element as
+		// it has its own depth.
+		if d.prevType == currentType && d.current != nil && currentType != SectionType {
+			content, forced := removeMarker(currentType, line)
+			d.current.Name = typeName(d.current.Type)
+			d.current.Forced = d.current.Forced || forced
+			d.appendLine(content, line, offset)
+		} else {
+			content, forced := removeMarker(currentType, line)
+			d.newElement(currentType, typeName(currentType), content, line, offset)
+			d.current.Forced = forced
+			if currentType == SceneHeadingType {
+				d.foundEndOfScript = isEndOfScript(d.current)
+			}
+		}
+	}
+	d.prevType = currentType
+}
+
+// isSpeech returns true for the elements following a character
+func isSpeech(element *Element) bool {
+	return element.Type == DialogueType || element.Type == ParentheticalType
+}
+
+// settle moves elements through the look ahead stages as far as the
+// queued elements allow.
+func (d *Decoder) settle() {
+	for d.settleCharacter() || d.settleDualDialogue() {
+	}
+}
+
+// settleCharacter applies the character fix-up to the first element
+// in the look ahead queue. It returns true if an element was moved on.
+func (d *Decoder) settleCharacter() bool {
+	if len(d.lookahead) == 0 {
+		return false
+	}
+	element := d.lookahead[0]
+	// NOTE: Character name lines required look ahead.
+	// I need to cleanup miss identified Character elements by
+	// applying dialaog is next element rule.
+	if !d.fixupDone && element.Type == CharacterType && !element.Forced && d.prevElementType == EmptyType {
+		if len(d.lookahead) < 2 {
+			if !d.eof {
+				return false
+			}
+			// NOTE: Character must be followed by dialog or
+			// parenthetical but the last element has been identified
+			// as a character element, what should this element be?
+			// We may just have an imcomplete script.
+		} else if !isSpeech(d.lookahead[1]) {
+			// What type are we?
+			element.Type = GeneralTextType
+		}
+	}
+	// If we're at the end of the script then we zero more characters.
+	if element.Type == SceneHeadingType && isEndOfScript(element) {
+		d.fixupDone = true
+	}
+	d.prevElementType = element.Type
+	d.lookahead = d.lookahead[1:]
+	d.grouping = append(d.grouping, element)
+	return true
+}
+
+// settleDualDialogue looks for a character's speech followed by a
+// second character marked with a caret, grouping the two into a
+// DualDialogueType element. It returns true if an element was released.
+func (d *Decoder) settleDualDialogue() bool {
+	q := d.grouping
+	if len(q) == 0 {
+		return false
+	}
+	// NOTE: Dual dialogue can only be identified once the second
+	// character is seen, hold the first speech until then.
+	if q[0].Type != CharacterType {
+		d.grouping = q[1:]
+		d.release(q[0])
+		return true
+	}
+	i := 1
+	for i < len(q) && isSpeech(q[i]) {
+		i++
+	}
+	if i == len(q) && !d.eof {
+		return false
+	}
+	j := i
+	for j < len(q) && q[j].Type == EmptyType {
+		j++
+	}
+	if j == len(q) && !d.eof {
+		return false
+	}
+	if i == 1 || j == len(q) || !isDualDialogueCharacter(q[j]) {
+		d.grouping = q[1:]
+		d.release(q[0])
+		return true
+	}
+	k := j + 1
+	for k < len(q) && isSpeech(q[k]) {
+		k++
+	}
+	if k == len(q) && !d.eof {
+		return false
+	}
+	dual := newDualDialogue(q[0:i], q[j:k])
+	// NOTE: the blank lines between the speeches are only kept in
+	// the source of the dual dialogue.
+	dual.Source = joinSource(q[0:k])
+	dual.ending = q[k-1].ending
+	d.grouping = q[k:]
+	d.release(dual)
+	return true
+}
+
+// release finishes parsing the element's content and makes it available
+// to Next().
+func (d *Decoder) release(element *Element) {
+	// NOTE: Notes, boneyard, scene numbers, section depth and inline
+	// emphasis are parsed once the element types are settled.
+	element.parseAnnotations()
+	element.parseSceneNumber()
+	element.parseDepth()
+	element.parseSpans()
+	for _, child := range element.Elements {
+		child.parseAnnotations()
+		child.parseSpans()
+	}
+	d.ready = append(d.ready, element)
+}
@@ -0,0 +1,224 @@
+// Package fountain is a Golang package supporting Fountain screenplay markup.
+//
+// emphasis.go handles parsing and rendering the inline emphasis markup
+// (e.g. *italic*, **bold**, ***bold italic***, _underline_).
+package fountain
+
+import (
+	"strings"
+)
+
+// Span is a run of styled text in an element's content. A span either
+// holds Text or a list of child Spans that share the span's Style.
+// Plain text spans have a Style of zero.
+//
+//	**bold *and italic***
+//
+// is parsed as a BoldStyle span holding a plain span "bold " and an
+// ItalicStyle span holding the plain span "and italic".
+type Span struct {
+	Style int     `json:"style,omitempty" yaml:"style,omitempty"`
+	Text  string  `json:"text,omitempty" yaml:"text,omitempty"`
+	Spans []*Span `json:"spans,omitempty" yaml:"spans,omitempty"`
+}
+
+// emphasisMarkers are the characters that can start or end a styled
+// run. A backslash only escapes these, e.g. in "C:\path" it is kept.
+const emphasisMarkers = "*_"
+
+// hasEmphasis returns true if line might hold inline emphasis or escapes
+func hasEmphasis(line string) bool {
+	return strings.ContainsAny(line, emphasisMarkers)
+}
+
+// styleForMarker maps a marker run to a span style
+func styleForMarker(marker string) int {
+	switch marker {
+	case "_":
+		return UnderlineStyle
+	case "**", "***":
+		return BoldStyle
+	default:
+		return ItalicStyle
+	}
+}
+
+// markerRun returns the marker at position i in s. Asterisk markers
+// are up to three characters long, underline markers one.
+func markerRun(s string, i int) string {
+	if s[i] == '_' {
+		return "_"
+	}
+	j := i
+	for j < len(s) && s[j] == '*' && j-i < 3 {
+		j++
+	}
+	return s[i:j]
+}
+
+// closesLater checks if the marker character shows up again on the same line.
+func closesLater(s string, i int, c byte) bool {
+	for ; i < len(s) && s[i] != '\n'; i++ {
+		if s[i] == '\\' && i+1 < len(s) && strings.IndexByte(emphasisMarkers, s[i+1]) >= 0 {
+			i++
+			continue
+		}
+		if s[i] == c {
+			return true
+		}
+	}
+	return false
+}
+
+// parseEmphasis scans s from position i until closer (if any) is found.
+// It returns the spans found, the position after the closer and if the
+// closer was found. Emphasis does not carry across line breaks.
+func parseEmphasis(s string, i int, closer string) ([]*Span, int, bool) {
+	spans := []*Span{}
+	buf := []byte{}
+	flush := func() {
+		if len(buf) > 0 {
+			spans = append(spans, &Span{Text: string(buf)})
+			buf = []byte{}
+		}
+	}
+	for i < len(s) {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s) && strings.IndexByte(emphasisMarkers, s[i+1]) >= 0:
+			buf = append(buf, s[i+1])
+			i += 2
+		case c == '\n' && closer != "":
+			// Emphasis can't span lines
+			return spans, i, false
+		case c == '*' || c == '_':
+			marker := markerRun(s, i)
+			if closer != "" && marker[0] == closer[0] && len(marker) == len(closer) {
+				flush()
+				return spans, i + len(marker), true
+			}
+			// Try to open a nested run
+			if closesLater(s, i+len(marker), c) {
+				inner, j, ok := parseEmphasis(s, i+len(marker), marker)
+				if ok && len(inner) > 0 {
+					flush()
+					span := &Span{Style: styleForMarker(marker), Spans: inner}
+					if marker == "***" {
+						span.Spans = []*Span{{Style: ItalicStyle, Spans: inner}}
+					}
+					spans = append(spans, span)
+					i = j
+					continue
+				}
+			}
+			// Close the current run if the marker is longer than the closer,
+			// e.g. "***" ending both "*" and "**"
+			if closer != "" && marker[0] == closer[0] && len(marker) > len(closer) {
+				flush()
+				return spans, i + len(closer), true
+			}
+			buf = append(buf, marker...)
+			i += len(marker)
+		default:
+			buf = append(buf, c)
+			i++
+		}
+	}
+	flush()
+	return spans, i, closer == ""
+}
+
+// ParseEmphasis takes a line of Fountain text and returns a list of
+// styled spans. Backslash escaped markers (e.g. `\*`) are treated as
+// plain text.
+func ParseEmphasis(line string) []*Span {
+	spans, _, _ := parseEmphasis(line, 0, "")
+	return spans
+}
+
+// isStyled returns true if any span carries a style
+func isStyled(spans []*Span) bool {
+	for _, span := range spans {
+		if span.Style != 0 || isStyled(span.Spans) {
+			return true
+		}
+	}
+	return false
+}
+
+// spansText returns the plain text of spans without any markup.
+func spansText(spans []*Span) string {
+	out := []string{}
+	for _, span := range spans {
+		if span.Spans != nil {
+			out = append(out, spansText(span.Spans))
+		} else {
+			out = append(out, span.Text)
+		}
+	}
+	return strings.Join(out, "")
+}
+
+// escapeEmphasis backslash escapes any marker characters in plain text.
+// A backslash is left as is, one before a marker is read back as text
+// since the marker after it is escaped too, e.g. `\*` is written `\\*`.
+func escapeEmphasis(s string) string {
+	if !hasEmphasis(s) {
+		return s
+	}
+	buf := []byte{}
+	for i := 0; i < len(s); i++ {
+		if strings.IndexByte(emphasisMarkers, s[i]) >= 0 {
+			buf = append(buf, '\\')
+		}
+		buf = append(buf, s[i])
+	}
+	return string(buf)
+}
+
+// spansToFountain renders spans back into Fountain markup.
+func spansToFountain(spans []*Span) string {
+	out := []string{}
+	for _, span := range spans {
+		switch {
+		case span.Style == BoldStyle && len(span.Spans) == 1 && span.Spans[0].Style == ItalicStyle:
+			out = append(out, "***"+spansToFountain(span.Spans[0].Spans)+"***")
+		case span.Style == BoldStyle:
+			out = append(out, "**"+spansToFountain(span.Spans)+"**")
+		case span.Style == ItalicStyle:
+			out = append(out, "*"+spansToFountain(span.Spans)+"*")
+		case span.Style == UnderlineStyle:
+			out = append(out, "_"+spansToFountain(span.Spans)+"_")
+		case span.Spans != nil:
+			out = append(out, spansToFountain(span.Spans))
+		default:
+			out = append(out, escapeEmphasis(span.Text))
+		}
+	}
+	return strings.Join(out, "")
+}
+
+// spansToHTML renders spans as HTML using <em>, <strong> and <u>. The
+// transform function (e.g. strings.ToUpper) is applied to text only.
+func spansToHTML(spans []*Span, transform func(string) string) string {
+	out := []string{}
+	for _, span := range spans {
+		switch span.Style {
+		case BoldStyle:
+			out = append(out, "<strong>"+spansToHTML(span.Spans, transform)+"</strong>")
+		case ItalicStyle:
+			out = append(out, "<em>"+spansToHTML(span.Spans, transform)+"</em>")
+		case UnderlineStyle:
+			out = append(out, "<u>"+spansToHTML(span.Spans, transform)+"</u>")
+		default:
+			if span.Spans != nil {
+				out = append(out, spansToHTML(span.Spans, transform))
+			} else if transform != nil {
+				out = append(out, transform(span.Text))
+			} else {
+				out = append(out, span.Text)
+			}
+		}
+	}
+	return strings.Join(out, "")
+}
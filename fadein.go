@@ -0,0 +1,72 @@
+// Package fountain is a Golang package supporting Fountain screenplay markup.
+//
+// fadein.go reads and writes FadeIn (.fadein) documents, a zip file
+// holding the screenplay as Open Screenplay Format XML.
+package fountain
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+)
+
+// fadeInDocument is the name of the OSF document in a FadeIn zip file
+const fadeInDocument = "document.xml"
+
+// ParseFadeIn takes the contents of a FadeIn (.fadein) file and returns
+// a Fountain struct.
+func ParseFadeIn(src []byte) (*Fountain, error) {
+	r, err := zip.NewReader(bytes.NewReader(src), int64(len(src)))
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range r.File {
+		if f.Name != fadeInDocument {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		osf, err := io.ReadAll(rc)
+		if err != nil {
+			return nil, err
+		}
+		return ParseOSF(osf)
+	}
+	return nil, fmt.Errorf("missing %s, not a FadeIn document", fadeInDocument)
+}
+
+// ParseFadeInFile takes a filename of a FadeIn document and returns
+// a Fountain struct.
+func ParseFadeInFile(fname string) (*Fountain, error) {
+	src, err := os.ReadFile(fname)
+	if err != nil {
+		return nil, err
+	}
+	return ParseFadeIn(src)
+}
+
+// ToFadeIn renders a Fountain document as a FadeIn (.fadein) file
+func (doc *Fountain) ToFadeIn() ([]byte, error) {
+	osf, err := doc.ToOSF()
+	if err != nil {
+		return nil, err
+	}
+	buf := new(bytes.Buffer)
+	w := zip.NewWriter(buf)
+	f, err := w.CreateHeader(&zip.FileHeader{Name: fadeInDocument, Method: zip.Deflate})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := f.Write(osf); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,46 @@
+package fountain
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"testing"
+)
+
+func TestFadeIn(t *testing.T) {
+	for i := 1; i <= 6; i++ {
+		fname := path.Join("testdata", fmt.Sprintf("sample-%02d.fadein", i))
+		doc, err := ParseFadeInFile(fname)
+		assertOK(t, err, "ParseFadeInFile("+fname+")")
+		// The FadeIn and Final Draft samples hold the same screenplay
+		fdx, err := ParseFDXFile(path.Join("testdata", fmt.Sprintf("sample-%02d.fdx", i)))
+		assertOK(t, err, "ParseFDXFile()")
+		if expected, got := fdx.String(), doc.String(); expected != got {
+			t.Errorf("%s: expected\n%s\ngot\n%s", fname, expected, got)
+		}
+		src, err := doc.ToFadeIn()
+		assertOK(t, err, "ToFadeIn()")
+		copied, err := ParseFadeIn(src)
+		assertOK(t, err, "ParseFadeIn(ToFadeIn())")
+		if expected, got := doc.String(), copied.String(); expected != got {
+			t.Errorf("%s: round trip changed the screenplay, expected\n%s\ngot\n%s", fname, expected, got)
+		}
+	}
+
+	doc, err := Parse([]byte("INT. KITCHEN - DAY #7#\n\nSteam rises from a **hot** pot.\n\nBOB\n(quietly)\nCareful.\n"))
+	assertOK(t, err, "Parse(src)")
+	src, err := doc.ToOSF()
+	assertOK(t, err, "ToOSF()")
+	for _, expected := range []string{
+		`<para number="7">`,
+		`<text bold="1">hot</text>`,
+		`<text>quietly</text>`,
+	} {
+		if !bytes.Contains(src, []byte(expected)) {
+			t.Errorf("expected %s in\n%s", expected, src)
+		}
+	}
+	if _, err := ParseFadeIn(src); err == nil {
+		t.Errorf("expected an error parsing OSF XML as a FadeIn zip file")
+	}
+}
@@ -0,0 +1,356 @@
+// Package fountain is a Golang package supporting Fountain screenplay markup.
+//
+// fdx.go imports and exports Final Draft (FDX) XML documents.
+package fountain
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// fdxDocument is the root element of a Final Draft document
+type fdxDocument struct {
+	XMLName      xml.Name      `xml:"FinalDraft"`
+	DocumentType string        `xml:"DocumentType,attr"`
+	Template     string        `xml:"Template,attr"`
+	Version      string        `xml:"Version,attr"`
+	Content      *fdxContent   `xml:"Content"`
+	TitlePage    *fdxTitlePage `xml:"TitlePage,omitempty"`
+}
+
+// fdxContent holds the script paragraphs
+type fdxContent struct {
+	Paragraphs []*fdxParagraph `xml:"Paragraph"`
+}
+
+// fdxTitlePage holds the title page paragraphs
+type fdxTitlePage struct {
+	Content *fdxContent `xml:"Content"`
+}
+
+// fdxParagraph is a single script element, dual dialogue is held
+// in a paragraph wrapping a DualDialogue element.
+type fdxParagraph struct {
+	Type            string              `xml:"Type,attr,omitempty"`
+	Number          string              `xml:"Number,attr,omitempty"`
+	Alignment       string              `xml:"Alignment,attr,omitempty"`
+	StartsNewPage   string              `xml:"StartsNewPage,attr,omitempty"`
+	SceneProperties *fdxSceneProperties `xml:"SceneProperties,omitempty"`
+	ScriptNotes     []*fdxScriptNote    `xml:"ScriptNote,omitempty"`
+	DualDialogue    *fdxContent         `xml:"DualDialogue,omitempty"`
+	Text            []*fdxText          `xml:"Text"`
+}
+
+// fdxSceneProperties describes a scene heading paragraph
+type fdxSceneProperties struct {
+	Length string `xml:"Length,attr,omitempty"`
+	Page   string `xml:"Page,attr,omitempty"`
+	Title  string `xml:"Title,attr"`
+}
+
+// fdxScriptNote is a note attached to a paragraph
+type fdxScriptNote struct {
+	ID         string          `xml:"ID,attr,omitempty"`
+	Paragraphs []*fdxParagraph `xml:"Paragraph"`
+}
+
+// fdxText is a run of text, Style combines Bold, Italic and Underline
+// with a plus sign (e.g. "Bold+Italic").
+type fdxText struct {
+	Style string `xml:"Style,attr,omitempty"`
+	Value string `xml:",chardata"`
+}
+
+// fdxTypes maps Final Draft paragraph types to element types
+var fdxTypes = map[string]int{
+	"Scene Heading": SceneHeadingType,
+	"Action":        ActionType,
+	"Character":     CharacterType,
+	"Dialogue":      DialogueType,
+	"Parenthetical": ParentheticalType,
+	"Transition":    TransitionType,
+	"Shot":          SceneHeadingType,
+	"Lyrics":        LyricType,
+	"General":       GeneralTextType,
+	"New Act":       SectionType,
+	"End of Act":    SectionType,
+}
+
+// text returns the plain text of a paragraph
+func (p *fdxParagraph) text() string {
+	out := []string{}
+	for _, t := range p.Text {
+		out = append(out, t.Value)
+	}
+	return strings.Join(out, "")
+}
+
+// spans returns the paragraph's text runs as spans
+func (p *fdxParagraph) spans() []*Span {
+	spans := []*Span{}
+	for _, t := range p.Text {
+		if t.Value == "" {
+			continue
+		}
+		style := "+" + t.Style + "+"
+		spans = append(spans, styledSpan(t.Value, strings.Contains(style, "+Bold+"),
+			strings.Contains(style, "+Italic+"), strings.Contains(style, "+Underline+")))
+	}
+	return spans
+}
+
+// fdxElement converts a script paragraph into an element, it returns nil
+// for empty paragraphs.
+func fdxElement(p *fdxParagraph) *Element {
+	elemType, ok := fdxTypes[p.Type]
+	if !ok {
+		elemType = GeneralTextType
+	}
+	return importedElement(elemType, p.Alignment == "Center", p.spans(), p.Number)
+}
+
+// fdxNotes converts the script notes attached to a paragraph into
+// NoteType elements.
+func fdxNotes(p *fdxParagraph) []*Element {
+	elements := []*Element{}
+	for _, note := range p.ScriptNotes {
+		lines := []string{}
+		for _, para := range note.Paragraphs {
+			lines = append(lines, para.text())
+		}
+		element := new(Element)
+		element.Type = NoteType
+		element.Name = typeName(NoteType)
+		element.Content = "[[" + strings.Join(lines, "\n") + "]]"
+		elements = append(elements, element)
+	}
+	return elements
+}
+
+// fdxDualDialogue converts the paragraphs of a DualDialogue element
+// into a DualDialogueType element.
+func fdxDualDialogue(content *fdxContent) *Element {
+	left, right := []*Element{}, []*Element{}
+	for _, p := range content.Paragraphs {
+		element := fdxElement(p)
+		if element == nil {
+			continue
+		}
+		if element.Type == CharacterType && len(left) > 0 {
+			right = append(right, element)
+		} else if len(right) > 0 {
+			right = append(right, element)
+		} else {
+			left = append(left, element)
+		}
+	}
+	if len(left) == 0 {
+		return nil
+	}
+	return newDualDialogue(left, right)
+}
+
+// fdxTitlePageElements maps the free form title page paragraphs to
+// title page keys.
+func fdxTitlePageElements(content *fdxContent) []*Element {
+	paragraphs := []*alignedText{}
+	for _, p := range content.Paragraphs {
+		paragraphs = append(paragraphs, &alignedText{align: strings.ToLower(p.Alignment), text: p.text()})
+	}
+	return inferTitlePage(paragraphs)
+}
+
+// ParseFDX takes a Final Draft (FDX) document and returns a Fountain
+// struct. Paragraph types are mapped to element types, dual dialogue
+// and scene numbers are kept and script notes become NoteType elements.
+func ParseFDX(src []byte) (*Fountain, error) {
+	fdx := new(fdxDocument)
+	if err := xml.Unmarshal(src, fdx); err != nil {
+		return nil, err
+	}
+	document := new(Fountain)
+	if fdx.TitlePage != nil && fdx.TitlePage.Content != nil {
+		document.TitlePage = fdxTitlePageElements(fdx.TitlePage.Content)
+	}
+	if fdx.Content == nil {
+		return document, nil
+	}
+	elements := []*Element{}
+	for _, p := range fdx.Content.Paragraphs {
+		for _, note := range fdxNotes(p) {
+			elements = appendElement(elements, note)
+		}
+		if p.StartsNewPage == "Yes" && len(elements) > 0 {
+			elements = appendElement(elements, newPageFeed())
+		}
+		var element *Element
+		if p.DualDialogue != nil {
+			element = fdxDualDialogue(p.DualDialogue)
+		} else {
+			element = fdxElement(p)
+		}
+		if element != nil {
+			elements = appendElement(elements, element)
+		}
+	}
+	document.Elements = elements
+	return document, nil
+}
+
+// ParseFDXFile takes a filename of a Final Draft document and returns
+// a Fountain struct.
+func ParseFDXFile(fname string) (*Fountain, error) {
+	src, err := os.ReadFile(fname)
+	if err != nil {
+		return nil, err
+	}
+	return ParseFDX(src)
+}
+
+// fdxStyle returns the Final Draft style attribute for a run style
+func fdxStyle(style int) string {
+	styles := []string{}
+	if style&runBold != 0 {
+		styles = append(styles, "Bold")
+	}
+	if style&runItalic != 0 {
+		styles = append(styles, "Italic")
+	}
+	if style&runUnderline != 0 {
+		styles = append(styles, "Underline")
+	}
+	return strings.Join(styles, "+")
+}
+
+// fdxTextRuns converts an element's content into text runs
+func fdxTextRuns(element *Element) []*fdxText {
+	runs := []*fdxText{}
+	for _, run := range exportRuns(element) {
+		runs = append(runs, &fdxText{Style: fdxStyle(run.style), Value: run.text})
+	}
+	return runs
+}
+
+// fdxAlignment returns the Final Draft alignment attribute
+func fdxAlignment(align string) string {
+	switch align {
+	case "center":
+		return "Center"
+	case "right":
+		return "Right"
+	case "left":
+		return "Left"
+	}
+	return ""
+}
+
+// fdxParagraphFor converts an element into a Final Draft paragraph, it
+// returns nil for elements that are not exported (e.g. empty lines,
+// sections and synopses).
+func fdxParagraphFor(element *Element) *fdxParagraph {
+	if element.Type == DualDialogueType {
+		dual := new(fdxContent)
+		for _, elem := range element.Elements {
+			if p := fdxParagraphFor(elem); p != nil {
+				dual.Paragraphs = append(dual.Paragraphs, p)
+			}
+		}
+		return &fdxParagraph{DualDialogue: dual}
+	}
+	paragraphType, align := exportStyle(element)
+	if paragraphType == "" {
+		return nil
+	}
+	p := &fdxParagraph{Type: paragraphType, Alignment: fdxAlignment(align), Text: fdxTextRuns(element)}
+	if element.Type == SceneHeadingType {
+		p.Number = element.SceneNumber
+	}
+	if len(p.Text) == 0 {
+		return nil
+	}
+	return p
+}
+
+// fdxNoteFor converts a NoteType element into a script note
+func fdxNoteFor(element *Element) *fdxScriptNote {
+	content := strings.TrimSpace(element.Content)
+	content = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(content, "[["), "]]"))
+	note := new(fdxScriptNote)
+	for _, line := range strings.Split(content, "\n") {
+		note.Paragraphs = append(note.Paragraphs, &fdxParagraph{Text: []*fdxText{{Value: strings.TrimSpace(line)}}})
+	}
+	return note
+}
+
+// fdxTitlePageFor lays out the title page paragraphs
+func fdxTitlePageFor(titlePage []*Element) *fdxTitlePage {
+	content := new(fdxContent)
+	for _, line := range titlePageLayout(titlePage) {
+		text := &fdxText{Style: fdxStyle(line.style), Value: line.text}
+		content.Paragraphs = append(content.Paragraphs, &fdxParagraph{Alignment: fdxAlignment(line.align), Text: []*fdxText{text}})
+	}
+	return &fdxTitlePage{Content: content}
+}
+
+// ToFDX renders a Fountain document as a Final Draft (FDX) XML document.
+// Notes are attached as script notes to the paragraph that follows or
+// holds them and forcing markers are removed from the text. Scene
+// headings carry the scene's length in eighths and page as laid out by
+// Paginate.
+func (doc *Fountain) ToFDX() ([]byte, error) {
+	fdx := new(fdxDocument)
+	fdx.DocumentType = "Script"
+	fdx.Template = "No"
+	fdx.Version = "3"
+	fdx.Content = new(fdxContent)
+	notes := []*fdxScriptNote{}
+	newPage := false
+	scenes := doc.Paginate(nil).Scenes
+	for _, element := range doc.Elements {
+		switch element.Type {
+		case NoteType:
+			notes = append(notes, fdxNoteFor(element))
+			continue
+		case PageFeed:
+			newPage = len(fdx.Content.Paragraphs) > 0
+			continue
+		}
+		p := fdxParagraphFor(element)
+		if p == nil {
+			continue
+		}
+		if isScene(element) && len(scenes) > 0 {
+			p.SceneProperties = &fdxSceneProperties{Length: scenes[0].Length, Page: fmt.Sprintf("%d", scenes[0].Page)}
+			scenes = scenes[1:]
+		}
+		p.ScriptNotes, notes = notes, []*fdxScriptNote{}
+		for _, annotation := range element.Annotations {
+			if annotation.Type == NoteType {
+				p.ScriptNotes = append(p.ScriptNotes, fdxNoteFor(&Element{Type: NoteType, Content: annotation.Content}))
+			}
+		}
+		if newPage {
+			p.StartsNewPage, newPage = "Yes", false
+		}
+		fdx.Content.Paragraphs = append(fdx.Content.Paragraphs, p)
+	}
+	if len(notes) > 0 {
+		// Notes at the end of the script need a paragraph to hold them
+		p := &fdxParagraph{Type: "General", ScriptNotes: notes, Text: []*fdxText{{}}}
+		fdx.Content.Paragraphs = append(fdx.Content.Paragraphs, p)
+	}
+	if len(doc.TitlePage) > 0 {
+		fdx.TitlePage = fdxTitlePageFor(doc.TitlePage)
+	}
+	src, err := xml.MarshalIndent(fdx, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	buf := bytes.NewBufferString(`<?xml version="1.0" encoding="UTF-8" standalone="no" ?>` + "\n")
+	buf.Write(src)
+	buf.WriteString("\n")
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,179 @@
+package fountain
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"strings"
+	"testing"
+)
+
+func TestParseFDX(t *testing.T) {
+	doc, err := ParseFDXFile(path.Join("testdata", "sample-01.fdx"))
+	assertOK(t, err, "ParseFDXFile(testdata/sample-01.fdx)")
+	expected := []struct {
+		Type    int
+		Content string
+		Forced  bool
+	}{
+		{ActionType, "FADE IN:", true},
+		{EmptyType, "", false},
+		{SceneHeadingType, "EXT. LIBRARY - DAY", false},
+		{EmptyType, "", false},
+		{ActionType, "A PROGRAMMER typing at an old laptop", false},
+		{EmptyType, "", false},
+		{CharacterType, "PROGRAMMER", false},
+		{ParentheticalType, "(excited)", false},
+		{DialogueType, "Eureka!", false},
+		{EmptyType, "", false},
+		{TransitionType, "FADE TO BLACK.", false},
+	}
+	if len(doc.Elements) != len(expected) {
+		t.Fatalf("expected %d elements, got %d", len(expected), len(doc.Elements))
+	}
+	for i, e := range expected {
+		element := doc.Elements[i]
+		if element.Type != e.Type || element.Content != e.Content || element.Forced != e.Forced {
+			t.Errorf("element %d: expected %s %q (forced %t), got %s %q (forced %t)", i, typeName(e.Type), e.Content, e.Forced, element.TypeName(), element.Content, element.Forced)
+		}
+	}
+
+	doc, err = ParseFDXFile(path.Join("testdata", "sample-04.fdx"))
+	assertOK(t, err, "ParseFDXFile(testdata/sample-04.fdx)")
+	titlePage := map[string]string{
+		"Title":      " SAMPLE 04",
+		"Credit":     " Written by",
+		"Author":     " Jane Doe",
+		"Draft date": " 2018-01-01",
+		"Copyright":  " Copyright (c) 2018",
+		"Contact":    "\n\tACME Examples Productions\n\t1234 5th Avenue\n\tAnytown, Planet Earth, 12345-7890",
+	}
+	if len(doc.TitlePage) != len(titlePage) {
+		t.Errorf("expected %d title page elements, got %d", len(titlePage), len(doc.TitlePage))
+	}
+	for _, element := range doc.TitlePage {
+		if expected, ok := titlePage[element.Name]; !ok || element.Content != expected {
+			t.Errorf("title page %q: expected %q, got %q", element.Name, expected, element.Content)
+		}
+	}
+	last := doc.Elements[len(doc.Elements)-1]
+	if last.Type != CenterAlignment || last.Content != ">THE END.<" {
+		t.Errorf("expected centered THE END., got %s %q", last.TypeName(), last.Content)
+	}
+
+	src := []byte(`<?xml version="1.0" encoding="UTF-8" standalone="no" ?>
+<FinalDraft DocumentType="Script" Template="No" Version="3">
+  <Content>
+    <Paragraph Number="4A" Type="Scene Heading">
+      <Text>INT. KITCHEN - DAY</Text>
+    </Paragraph>
+    <Paragraph Type="Action">
+      <ScriptNote ID="1">
+        <Paragraph><Text>Check the props.</Text></Paragraph>
+      </ScriptNote>
+      <Text>Steam rises from a </Text>
+      <Text Style="Bold+Italic">very</Text>
+      <Text Style="Underline"> hot</Text>
+      <Text> pot.</Text>
+    </Paragraph>
+    <Paragraph>
+      <DualDialogue>
+        <Paragraph Type="Character"><Text>BRICK</Text></Paragraph>
+        <Paragraph Type="Dialogue"><Text>Careful!</Text></Paragraph>
+        <Paragraph Type="Character"><Text>STEEL</Text></Paragraph>
+        <Paragraph Type="Dialogue"><Text>Hot!</Text></Paragraph>
+      </DualDialogue>
+    </Paragraph>
+    <Paragraph Type="General">
+      <Text></Text>
+    </Paragraph>
+  </Content>
+</FinalDraft>`)
+	doc, err = ParseFDX(src)
+	assertOK(t, err, "ParseFDX(src)")
+	types := []string{}
+	for _, element := range doc.Elements {
+		types = append(types, element.TypeName())
+	}
+	if strings.Join(types, ",") != "Scene Heading,Empty,Note,Empty,Action,Empty,Dual Dialogue" {
+		t.Fatalf("unexpected element types %s", strings.Join(types, ","))
+	}
+	if doc.Elements[0].SceneNumber != "4A" {
+		t.Errorf("expected scene number 4A, got %q", doc.Elements[0].SceneNumber)
+	}
+	if doc.Elements[2].Content != "[[Check the props.]]" {
+		t.Errorf("unexpected note %q", doc.Elements[2].Content)
+	}
+	if action := doc.Elements[4]; action.Content != "Steam rises from a ***very***_ hot_ pot." || action.Spans == nil {
+		t.Errorf("unexpected action %q", action.Content)
+	}
+	left, right := doc.Elements[6].DualDialogueBlocks()
+	if len(left) != 2 || len(right) != 2 || CharacterName(right[0]) != "STEEL" {
+		t.Errorf("unexpected dual dialogue %s", doc.Elements[6])
+	}
+	if s := doc.String(); !strings.Contains(s, "STEEL ^") || !strings.Contains(s, "INT. KITCHEN - DAY #4A#") {
+		t.Errorf("unexpected fountain output\n%s", s)
+	}
+}
+
+func TestFDXRoundTrip(t *testing.T) {
+	for i := 1; i <= 6; i++ {
+		fname := path.Join("testdata", fmt.Sprintf("sample-%02d.fdx", i))
+		doc, err := ParseFDXFile(fname)
+		assertOK(t, err, "ParseFDXFile("+fname+")")
+		src, err := doc.ToFDX()
+		assertOK(t, err, "ToFDX()")
+		if !bytes.Contains(src, []byte(`<FinalDraft DocumentType="Script"`)) {
+			t.Errorf("%s: expected a FinalDraft script document", fname)
+		}
+		copied, err := ParseFDX(src)
+		assertOK(t, err, "ParseFDX(ToFDX())")
+		if expected, got := doc.String(), copied.String(); expected != got {
+			t.Errorf("%s: round trip changed the screenplay, expected\n%s\ngot\n%s", fname, expected, got)
+		}
+	}
+
+	src := []byte(`Title: Round Trip
+Credit: Written by
+Author: Jane Doe
+Draft date: 2018-01-01
+
+INT. KITCHEN - DAY #7#
+
+[[Check the props.]]
+
+Steam rises from a ***very*** _hot_ pot.
+
+===
+
+EXT. GARDEN - NIGHT
+
+Crickets.
+`)
+	doc, err := Parse(src)
+	assertOK(t, err, "Parse(src)")
+	fdx, err := doc.ToFDX()
+	assertOK(t, err, "ToFDX()")
+	for _, expected := range []string{
+		`<Paragraph Type="Scene Heading" Number="7">`,
+		`<Text>Check the props.</Text>`,
+		`<Text Style="Bold+Italic">very</Text>`,
+		`<Text Style="Underline">hot</Text>`,
+		`<Paragraph Type="Scene Heading" StartsNewPage="Yes">`,
+		`<Text Style="Underline">Round Trip</Text>`,
+	} {
+		if !bytes.Contains(fdx, []byte(expected)) {
+			t.Errorf("expected %s in\n%s", expected, fdx)
+		}
+	}
+	copied, err := ParseFDX(fdx)
+	assertOK(t, err, "ParseFDX(ToFDX())")
+	for _, element := range copied.TitlePage {
+		if element.Name == "Draft date" && element.Content != " 2018-01-01" {
+			t.Errorf("expected draft date to round trip, got %q", element.Content)
+		}
+	}
+	if copied.Elements[2].Type != NoteType || copied.Elements[2].Content != "[[Check the props.]]" {
+		t.Errorf("expected note to round trip, got %s %q", copied.Elements[2].TypeName(), copied.Elements[2].Content)
+	}
+}
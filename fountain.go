@@ -30,11 +30,9 @@
 package fountain
 
 import (
-	"bufio"
 	"bytes"
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
 	"log"
 	"os"
 	"regexp"
@@ -107,10 +105,26 @@ const (
 
 	// PageFeed - inject a page feed or <hr> in HTML
 	PageFeed
+
+	// DualDialogueType - holds two character/parenthetical/dialogue
+	// blocks spoken at the same time, the second character is marked
+	// with a trailing caret (^) in Fountain.
+	DualDialogueType
 )
 
 var (
-	reSceneNo = regexp.MustCompile(`#*#$`)
+	// reSceneNo matches a trailing scene number, e.g. #1#, #1A#, #I-1-A#
+	reSceneNo = regexp.MustCompile(`\s*#([A-Za-z0-9.\-]+)#\s*$`)
+	// reSetting matches the setting starting a scene heading followed
+	// by a dot or a space, e.g. "INT.", "INT.HOUSE", "EXT ", "EST.",
+	// "INT./EXT.", "I/E" but not "INTO"
+	reSetting = regexp.MustCompile(`(?i)^(INT\.?\s*/\s*EXT|EXT\.?\s*/\s*INT|I\s*/\s*E|INT|EXT|EST)(\.|\s|$)`)
+	// reForcedHeading matches a scene heading forced with a period,
+	// e.g. ".FLASHBACK" but not an ellipsis "..."
+	reForcedHeading = regexp.MustCompile(`^\.[\pL\pN]`)
+	// reTitlePageKey matches an unindented title page key, e.g.
+	// "Draft date:" but not "  Suite 2: back" or "!FADE IN:"
+	reTitlePageKey = regexp.MustCompile(`^[\pL\pN][^:]*:`)
 	// MaxWidth used to set width for Fountain text output in String()
 	MaxWidth = 64
 	// AsHTMLPage if true generate the HTML header and footer blocks
@@ -128,19 +142,85 @@ var (
 	ShowSynopsis = false
 	// ShowNotes - preserve notes in output (e.g. when pretty printing a working draft)
 	ShowNotes = false
+	// ShowBoneyard - preserve boneyard (cut material) in output
+	ShowBoneyard = false
 
 	// Pretty Print - will pretty print for output (e.g. when turning into
 	// JSON, use MarshalIndent)
 	PrettyPrint = false
 )
 
+// Options holds the rendering settings used by the Render* methods.
+// Unlike the package level variables an Options value is passed per call
+// so screenplays can be rendered concurrently with different settings.
+//
+//	opt := fountain.DefaultOptions()
+//	opt.ShowNotes = true
+//	fmt.Println(screenplay.RenderString(opt))
+type Options struct {
+	// MaxWidth used to set width for Fountain text output
+	MaxWidth int
+	// AsHTMLPage if true generate the HTML header and footer blocks
+	AsHTMLPage bool
+	// InlineCSS sets behavior of including style elements with CSS
+	InlineCSS bool
+	// LinkCSS sets behavior of including link element pointing to CSS file
+	LinkCSS bool
+	// CSS holds the filename to use generating CSS links or reading
+	// in a customized version of the CSS.
+	CSS string
+	// ShowSection - preserve section markers in output
+	ShowSection bool
+	// ShowSynopsis - preserve synopsis in output
+	ShowSynopsis bool
+	// ShowNotes - preserve notes in output
+	ShowNotes bool
+	// ShowBoneyard - preserve boneyard in output
+	ShowBoneyard bool
+	// PrettyPrint - pretty print JSON output
+	PrettyPrint bool
+	// PaperSize used by RenderPDF, "letter" or "a4". Defaults to US Letter.
+	PaperSize string
+}
+
+// DefaultOptions returns an Options populated from the package level
+// variables (e.g. MaxWidth, AsHTMLPage, ShowNotes).
+func DefaultOptions() *Options {
+	return &Options{
+		MaxWidth:     MaxWidth,
+		AsHTMLPage:   AsHTMLPage,
+		InlineCSS:    InlineCSS,
+		LinkCSS:      LinkCSS,
+		CSS:          CSS,
+		ShowSection:  ShowSection,
+		ShowSynopsis: ShowSynopsis,
+		ShowNotes:    ShowNotes,
+		ShowBoneyard: ShowBoneyard,
+		PrettyPrint:  PrettyPrint,
+	}
+}
+
+// options returns opt or the package defaults if opt is nil.
+func options(opt *Options) *Options {
+	if opt == nil {
+		return DefaultOptions()
+	}
+	return opt
+}
+
 // Fountain is the document container. It is the type returned by Parse() and ParseFile()
 //
 //	screenplay, _ := ParseFile("screenplay.fountain")
 //	fmt.Println(screenplay.String())
 type Fountain struct {
-	TitlePage []*Element
-	Elements  []*Element
+	TitlePage []*Element `json:"TitlePage" yaml:"TitlePage"`
+	Elements  []*Element `json:"Elements" yaml:"Elements"`
+
+	// newline is the line ending of the source's first line ("" is
+	// "\n"), used for elements without a source, and noFinalNewline is
+	// true if the source's last line has no ending.
+	newline        string
+	noFinalNewline bool
 }
 
 // Element holds the parsed token in either the title page of the document or
@@ -149,6 +229,62 @@ type Element struct {
 	Type    int    `json:"type" yaml:"type"`
 	Name    string `json:"name,omitempty" yaml:"name,omitempty"`
 	Content string `json:"content" yaml:"content"`
+	// Spans holds the parsed inline emphasis of Content, it is only
+	// populated when Content contains emphasis markup.
+	Spans []*Span `json:"spans,omitempty" yaml:"spans,omitempty"`
+	// Start and End record where the element was found in the source.
+	// End points just past the last character of the element.
+	Start *Position `json:"start,omitempty" yaml:"start,omitempty"`
+	End   *Position `json:"end,omitempty" yaml:"end,omitempty"`
+	// SceneNumber holds the scene number of a SceneHeadingType element
+	// (e.g. "1A" for `INT. HOUSE - DAY #1A#`).
+	SceneNumber string `json:"scene_number,omitempty" yaml:"scene_number,omitempty"`
+	// Forced is true if the element type was forced with a marker (e.g.
+	// "!" for action, "@" for a character), the marker is not kept in
+	// Content.
+	Forced bool `json:"forced,omitempty" yaml:"forced,omitempty"`
+	// Depth holds the level of a SectionType element, 1 for "# Act",
+	// 2 for "## Sequence", etc.
+	Depth int `json:"depth,omitempty" yaml:"depth,omitempty"`
+	// Elements holds the child elements of a DualDialogueType element.
+	Elements []*Element `json:"elements,omitempty" yaml:"elements,omitempty"`
+	// Annotations holds the notes and boneyard found in the text of the
+	// element, they are removed from Content.
+	Annotations []*Annotation `json:"annotations,omitempty" yaml:"annotations,omitempty"`
+	// Source holds the lines of the element as written with their line
+	// endings (without the final line ending), RenderSource uses it to
+	// write the screenplay back unchanged.
+	Source string `json:"-" yaml:"-"`
+	// ending is the line ending after the last line of the source
+	ending string
+}
+
+// Position is a location in the Fountain source. Line and Column start
+// at one, Offset is the zero based byte offset into the source.
+type Position struct {
+	Line   int `json:"line" yaml:"line"`
+	Column int `json:"column" yaml:"column"`
+	Offset int `json:"offset" yaml:"offset"`
+}
+
+// String returns the position as "line:column"
+func (pos *Position) String() string {
+	if pos == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%d:%d", pos.Line, pos.Column)
+}
+
+// setStart records the start of the element as the beginning of
+// a line.
+func (element *Element) setStart(lineNo int, offset int) {
+	element.Start = &Position{Line: lineNo, Column: 1, Offset: offset}
+}
+
+// setEnd records the end of the element as the end of the line that
+// starts at offset.
+func (element *Element) setEnd(lineNo int, offset int, line string) {
+	element.End = &Position{Line: lineNo, Column: len(line) + 1, Offset: offset + len(line)}
 }
 
 func typeName(t int) string {
@@ -199,10 +335,55 @@ func typeName(t int) string {
 		return "Section"
 	case SynopsisType:
 		return "Synopsis"
+	case DualDialogueType:
+		return "Dual Dialogue"
 	}
 	return ""
 }
 
+// hasInlineStyles returns true if the element type's content can contain
+// inline emphasis (e.g. notes, sections and boneyard are left as is).
+func hasInlineStyles(t int) bool {
+	switch t {
+	case TitlePageType, SceneHeadingType, ActionType, CharacterType,
+		DialogueType, ParentheticalType, LyricType, CenterAlignment,
+		LeftAlignment, RightAlignment, GeneralTextType:
+		return true
+	}
+	return false
+}
+
+// parseSpans populates element.Spans if the content holds inline emphasis.
+func (element *Element) parseSpans() {
+	element.Spans = nil
+	if hasInlineStyles(element.Type) && hasEmphasis(element.Content) {
+		spans := ParseEmphasis(element.Content)
+		if isStyled(spans) || spansText(spans) != element.Content {
+			element.Spans = spans
+		}
+	}
+}
+
+// text returns the element content as Fountain markup
+func (element *Element) text() string {
+	if element.Spans != nil {
+		return spansToFountain(element.Spans)
+	}
+	return element.Content
+}
+
+// html returns the element content with inline emphasis rendered as HTML.
+// The transform function (e.g. strings.ToUpper) is applied to the text.
+func (element *Element) html(transform func(string) string) string {
+	if element.Spans != nil {
+		return spansToHTML(element.Spans, transform)
+	}
+	if transform != nil {
+		return transform(element.Content)
+	}
+	return element.Content
+}
+
 // TypeName returns the string describing the type of Fountain Element.
 func (element *Element) TypeName() string {
 	return typeName(element.Type)
@@ -215,9 +396,9 @@ func (element *Element) TypeName() string {
 func CharacterName(element *Element) string {
 	characters := []string{}
 	if element.Type == CharacterType {
-		content := strings.TrimSpace(element.Content)
+		content := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(element.Content), "^"))
 		if !(strings.HasPrefix(content, `"`) && strings.HasSuffix(content, `"`)) {
-			contentParts := strings.Split(element.Content, " ")
+			contentParts := strings.Split(content, " ")
 			for _, content := range contentParts {
 				content = strings.TrimSpace(content)
 				// If not a parenthetical or concatentation record as
@@ -242,57 +423,191 @@ func CharacterName(element *Element) string {
 	return strings.Join(characters, " ")
 }
 
-// wordWrap will try to break line at a suitable place if they are equal or
-// longer than width.
-func wordWrap(line string, width int) string {
-	if len(line) <= width {
-		return line
+// parseSceneNumber moves a trailing scene number from a scene heading's
+// content into element.SceneNumber.
+func (element *Element) parseSceneNumber() {
+	if element.Type != SceneHeadingType {
+		return
 	}
-	buf := []string{}
-	words := strings.Split(line, " ")
-	l := 0
-	for _, word := range words {
-		if l+len(word) < width {
-			if len(buf) > 0 {
-				buf = append(buf, " ", word)
-				l += len(word) + 1
-			} else {
-				buf = append(buf, word)
-				l += len(word)
+	if m := reSceneNo.FindStringSubmatchIndex(element.Content); m != nil {
+		element.SceneNumber = element.Content[m[2]:m[3]]
+		element.Content = element.Content[0:m[0]]
+	}
+}
+
+// parseDepth sets the depth of a SectionType element from the number of
+// "#" it starts with.
+func (element *Element) parseDepth() {
+	if element.Type != SectionType {
+		return
+	}
+	content := strings.TrimSpace(element.Content)
+	element.Depth = max(len(content)-len(strings.TrimLeft(content, "#")), 1)
+}
+
+// isScene returns true if the element is a scene heading that starts a
+// scene (e.g. not "FADE IN:" or "THE END.")
+func isScene(element *Element) bool {
+	if element.Type != SceneHeadingType || isEndOfScript(element) {
+		return false
+	}
+	return strings.Compare(strings.ToUpper(strings.TrimSpace(element.Content)), "FADE IN:") != 0
+}
+
+// sceneSuffix returns the letter suffix used for inserted scenes,
+// 0 is "A", 25 is "Z", 26 is "AA".
+func sceneSuffix(i int) string {
+	if i < 26 {
+		return string(rune('A' + i))
+	}
+	return sceneSuffix(i/26-1) + sceneSuffix(i%26)
+}
+
+// NumberScenes assigns scene numbers to the scene headings. If renumber
+// is true (or no scene has a number yet) the scenes are numbered
+// sequentially from one. Otherwise existing numbers are kept as is (i.e.
+// locked for a production draft) and scenes without a number are given the
+// previous scene's number plus a letter (e.g. 5A, 5B). Scenes before the
+// first numbered scene get a letter prefix (e.g. A1, B1). Letters giving
+// a number already in use (e.g. 5A after 5 when 5A follows) are skipped.
+func (doc *Fountain) NumberScenes(renumber bool) {
+	scenes := []*Element{}
+	// taken holds the scene numbers in use, upper case
+	taken := map[string]bool{}
+	for _, element := range doc.Elements {
+		if isScene(element) {
+			scenes = append(scenes, element)
+			if element.SceneNumber != "" {
+				taken[strings.ToUpper(element.SceneNumber)] = true
 			}
-		} else {
-			buf = append(buf, "\n", word)
-			l = 0
 		}
 	}
-	return strings.Join(buf, "") + "\n"
+	if renumber || len(taken) == 0 {
+		for i, element := range scenes {
+			element.SceneNumber = fmt.Sprintf("%d", i+1)
+		}
+		return
+	}
+	// free returns the first number from the i-th letter on that is
+	// not in use
+	free := func(i int, number func(string) string) (string, int) {
+		for taken[strings.ToUpper(number(sceneSuffix(i)))] {
+			i++
+		}
+		s := number(sceneSuffix(i))
+		taken[strings.ToUpper(s)] = true
+		return s, i + 1
+	}
+	leading := []*Element{}
+	prev, suffix := "", 0
+	for _, element := range scenes {
+		switch {
+		case element.SceneNumber != "":
+			if prev == "" {
+				i := 0
+				for _, scene := range leading {
+					scene.SceneNumber, i = free(i, func(letter string) string {
+						return letter + element.SceneNumber
+					})
+				}
+			}
+			prev, suffix = element.SceneNumber, 0
+		case prev == "":
+			leading = append(leading, element)
+		default:
+			element.SceneNumber, suffix = free(suffix, func(letter string) string {
+				return prev + letter
+			})
+		}
+	}
 }
 
-// blockWrap will add left/right padding and wrap the text in the block
-func blockWrap(line, padding string, width int) string {
-	// NOTE: We need to adjust width to reflect padding on right
-	width = width - len(padding)
-	if len(padding)+len(line) <= width {
-		return padding + line
+// DualDialogueBlocks returns the left (first) and right (second) speeches
+// of a DualDialogueType element. Each block starts with a CharacterType
+// element.
+func (element *Element) DualDialogueBlocks() ([]*Element, []*Element) {
+	if element.Type != DualDialogueType {
+		return nil, nil
 	}
-	buf := []string{}
-	words := strings.Split(line, " ")
-	l := 0
-	for _, word := range words {
-		if l+len(word) < width {
-			if len(buf) > 0 {
-				buf = append(buf, " ", word)
-				l += len(word) + 1
-			} else {
-				buf = append(buf, padding, word)
-				l += len(padding) + len(word)
-			}
-		} else {
-			buf = append(buf, "\n", padding, word)
-			l = len(padding) + len(word)
+	for i, elem := range element.Elements {
+		if i > 0 && elem.Type == CharacterType {
+			return element.Elements[0:i], element.Elements[i:]
+		}
+	}
+	return element.Elements, nil
+}
+
+// isDualDialogueCharacter returns true if the element is a character
+// marked with a trailing caret.
+func isDualDialogueCharacter(element *Element) bool {
+	return element.Type == CharacterType && strings.HasSuffix(strings.TrimSpace(element.Content), "^")
+}
+
+// newDualDialogue assembles a DualDialogueType element from the left
+// and right speeches. The caret is removed from the right character.
+func newDualDialogue(left []*Element, right []*Element) *Element {
+	if len(right) > 0 {
+		right[0].Content = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(right[0].Content), "^"))
+	}
+	dual := new(Element)
+	dual.Type = DualDialogueType
+	dual.Name = typeName(dual.Type)
+	dual.Elements = append(dual.Elements, left...)
+	dual.Elements = append(dual.Elements, right...)
+	if len(dual.Elements) > 0 {
+		dual.Start = dual.Elements[0].Start
+		dual.End = dual.Elements[len(dual.Elements)-1].End
+	}
+	return dual
+}
+
+// wrapLine breaks a line at the spaces so each part fits in width. A
+// line that fits is left as is and a word longer than width is left on
+// a line of its own, so wrapping the parts again doesn't change them.
+func wrapLine(line string, width int) []string {
+	if len(line) <= width {
+		return []string{line}
+	}
+	// Action keeps its indent on the first line
+	text := strings.TrimLeft(line, " \t")
+	current := line[0 : len(line)-len(text)]
+	lines := []string{}
+	for _, word := range strings.Split(text, " ") {
+		switch {
+		case strings.TrimSpace(current) == "":
+			current += word
+		case len(current)+1+len(word) <= width:
+			current += " " + word
+		default:
+			lines = append(lines, current)
+			current = word
+		}
+	}
+	return append(lines, current)
+}
+
+// wordWrap will break each line of the text at a suitable place if it
+// is longer than width.
+func wordWrap(text string, width int) string {
+	lines := []string{}
+	for _, line := range strings.Split(text, "\n") {
+		lines = append(lines, wrapLine(line, width)...)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// padLines adds the padding to each line of the text, the lines are
+// trimmed first so the padding isn't added twice when the text is
+// formatted again. Blank lines (e.g. inside a boneyard) are left empty.
+func padLines(text, padding string) string {
+	lines := []string{}
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			line = padding + line
 		}
+		lines = append(lines, line)
 	}
-	return strings.Join(buf, "") + "\n"
+	return strings.Join(lines, "\n")
 }
 
 // centerAlignText center align text given a line and width
@@ -342,53 +657,102 @@ func rightAlignText(line string, width int) string {
 
 // String() considers elem.Type and formatting output as string
 func (element *Element) String() string {
+	return element.RenderString(nil)
+}
+
+// RenderString considers elem.Type and the provided options formatting
+// output as string. If opt is nil the package defaults are used.
+func (element *Element) RenderString(opt *Options) string {
+	opt = options(opt)
 	switch element.Type {
 	case TitlePageType:
-		return element.Name + ":" + element.Content
+		return titlePageString(element.Name, element.textFor(opt, nil))
 	case SceneHeadingType:
-		return strings.ToUpper(strings.TrimSpace(element.Content))
+		s := strings.TrimSpace(element.textFor(opt, strings.ToUpper))
+		s = forceMarker(SceneHeadingType, s, EmptyType) + s
+		if element.SceneNumber != "" {
+			return s + " #" + element.SceneNumber + "#"
+		}
+		return s
 	case ActionType:
-		return wordWrap(element.Content, MaxWidth)
+		s := element.textFor(opt, nil)
+		if element.Forced && strings.TrimSpace(s) == "" {
+			// e.g. a "!" alone ending the dialogue above it
+			return "!"
+		}
+		return forceLines(ActionType, wordWrap(s, opt.MaxWidth))
 	case CharacterType:
-		return strings.Repeat("    ", 4) + strings.ToUpper(strings.TrimSpace(element.Content))
+		s := strings.TrimSpace(element.textFor(opt, strings.ToUpper))
+		return strings.Repeat("    ", 4) + forceMarker(CharacterType, s, EmptyType) + s
 	case ParentheticalType:
-		return strings.Repeat("    ", 3) + strings.TrimSpace(element.Content)
+		return strings.Repeat("    ", 3) + strings.TrimSpace(element.textFor(opt, nil))
 	case DialogueType:
-		return blockWrap(element.Content, strings.Repeat("    ", 2), MaxWidth)
+		// NOTE: dialogue isn't wrapped, a line following dialogue is
+		// read as action.
+		return padLines(element.textFor(opt, nil), strings.Repeat("    ", 2))
 	case TransitionType:
 		s := strings.TrimSpace(element.Content)
-		if strings.HasSuffix(s, ".") || strings.HasSuffix(s, "IN:") {
-			return leftAlignText(s, MaxWidth)
-		}
 		if strings.HasPrefix(s, ">") && strings.HasSuffix(s, "<") {
-			return centerAlignText(strings.ToUpper(element.Content), MaxWidth)
+			return centerAlignText(strings.ToUpper(element.Content), opt.MaxWidth)
 		}
-		return rightAlignText(strings.ToUpper(element.Content), MaxWidth)
+		s = strings.ToUpper(s)
+		leftAlign := strings.HasSuffix(s, ".") || strings.HasSuffix(s, "IN:")
+		marker := forceMarker(TransitionType, s, EmptyType)
+		if element.Forced && leftAlign {
+			// The marker also keeps the transition right aligned
+			marker = ">"
+		}
+		if marker == "" && leftAlign {
+			return leftAlignText(s, opt.MaxWidth)
+		}
+		return rightAlignText(marker+s, opt.MaxWidth)
+	case LyricType:
+		return forceLines(LyricType, element.textFor(opt, nil))
 	case CenterAlignment:
-		return centerAlignText(element.Content, MaxWidth)
+		return centerAlignText(element.textFor(opt, nil), opt.MaxWidth)
 	case LeftAlignment:
-		return leftAlignText(element.Content, MaxWidth)
+		return leftAlignText(element.textFor(opt, nil), opt.MaxWidth)
 	case RightAlignment:
-		return rightAlignText(element.Content, MaxWidth)
+		return rightAlignText(element.textFor(opt, nil), opt.MaxWidth)
 	case NoteType:
-		if ShowNotes {
+		if opt.ShowNotes {
+			return element.Content
+		}
+		return ""
+	case BoneyardType:
+		if opt.ShowBoneyard {
 			return element.Content
 		}
 		return ""
 	case SectionType:
-		if ShowSection {
+		if opt.ShowSection {
 			return element.Content
 		}
 		return ""
 	case SynopsisType:
-		if ShowSynopsis {
+		if opt.ShowSynopsis {
 			return element.Content
 		}
 		return ""
 	case PageFeed:
-		return "\f"
+		return "==="
+	case DualDialogueType:
+		left, right := element.DualDialogueBlocks()
+		src := []string{}
+		for _, elem := range left {
+			src = append(src, elem.RenderString(opt))
+		}
+		src = append(src, "")
+		for i, elem := range right {
+			if i == 0 {
+				src = append(src, elem.RenderString(opt)+" ^")
+			} else {
+				src = append(src, elem.RenderString(opt))
+			}
+		}
+		return strings.Join(src, "\n")
 	default:
-		return element.Content
+		return element.textFor(opt, nil)
 	}
 }
 
@@ -410,88 +774,123 @@ func createElement(elem string, classes []string, content string) string {
 
 // ToHTML considers elem.Type and formatting output
 func (element *Element) ToHTML() string {
+	return element.RenderHTML(nil)
+}
+
+// RenderHTML considers elem.Type and the provided options formatting
+// output as HTML. If opt is nil the package defaults are used.
+func (element *Element) RenderHTML(opt *Options) string {
+	opt = options(opt)
 	switch element.Type {
 	case TitlePageType:
-		switch strings.ToLower(element.Name) {
-		case "title":
-			return createElement("div", []string{"title"}, element.Content)
-		case "author":
-			return createElement("div", []string{"author"}, element.Content)
-		case "draft date":
-			return createElement("div", []string{"draft-date"}, element.Content)
-		case "date":
-			return createElement("div", []string{"draft-date"}, element.Content)
-		case "copyright":
-			return createElement("div", []string{"copyright"}, element.Content)
-		case "contact":
-			return createElement("div", []string{"contact"}, element.Content)
-		default:
-			return createElement("div", []string{"general-text"}, element.Content)
+		key := titlePageKey(element.Name)
+		if new(TitlePage).field(key) == nil {
+			key = "general text"
 		}
+		return titlePageHTML(strings.ReplaceAll(key, " ", "-"), strings.Join(titlePageLines(element.text()), "\n"))
 	case SceneHeadingType:
-		return createElement("div", []string{"scene-heading"}, strings.ToUpper(strings.TrimSpace(element.Content)))
+		if element.SceneNumber != "" {
+			return createElement("div", []string{"scene-heading"},
+				createElement("span", []string{"scene-number-left"}, element.SceneNumber)+
+					strings.TrimSpace(element.htmlFor(opt, strings.ToUpper))+
+					createElement("span", []string{"scene-number-right"}, element.SceneNumber))
+		}
+		return createElement("div", []string{"scene-heading"}, strings.TrimSpace(element.htmlFor(opt, strings.ToUpper)))
 	case ActionType:
-		return createElement("div", []string{"action"}, element.Content)
+		return createElement("div", []string{"action"}, element.htmlFor(opt, nil))
 	case CharacterType:
-		return createElement("div", []string{"character"}, strings.ToUpper(strings.TrimSpace(element.Content)))
+		return createElement("div", []string{"character"}, strings.TrimSpace(element.htmlFor(opt, strings.ToUpper)))
 	case ParentheticalType:
-		return createElement("div", []string{"parenthetical"}, strings.TrimSpace(element.Content))
+		return createElement("div", []string{"parenthetical"}, strings.TrimSpace(element.htmlFor(opt, nil)))
 	case DialogueType:
-		return createElement("div", []string{"dialogue"}, element.Content)
+		return createElement("div", []string{"dialogue"}, element.htmlFor(opt, nil))
 	case TransitionType:
 		s := strings.TrimSpace(element.Content)
 		if strings.HasPrefix(s, ">") && strings.HasSuffix(s, "<") {
 			return createElement("div", []string{"transition", "centered"}, strings.TrimPrefix(strings.TrimSuffix(s, "<"), ">"))
 		}
-		if strings.HasPrefix(s, ">") {
-			return createElement("div", []string{"transition", "right-align"}, strings.TrimPrefix(s, ">"))
-		}
-		if strings.HasSuffix(s, ".") || strings.HasSuffix(s, "IN:") {
+		if !element.Forced && (strings.HasSuffix(s, ".") || strings.HasSuffix(s, "IN:")) {
 			return createElement("div", []string{"transition", "left-align"}, s)
 		}
-		return createElement("div", []string{"transition", "right-align"}, strings.ToUpper(element.Content))
+		return createElement("div", []string{"transition", "right-align"}, strings.ToUpper(s))
 	case CenterAlignment:
-		return createElement("div", []string{"centered"}, element.Content)
+		return createElement("div", []string{"centered"}, element.htmlFor(opt, nil))
 	case LeftAlignment:
-		return createElement("div", []string{"left-align"}, element.Content)
+		return createElement("div", []string{"left-align"}, element.htmlFor(opt, nil))
 	case RightAlignment:
-		return createElement("div", []string{"right-align"}, element.Content)
+		return createElement("div", []string{"right-align"}, element.htmlFor(opt, nil))
+	case NoteType:
+		if !opt.ShowNotes {
+			return ""
+		}
+		return createElement("div", []string{"note"}, element.Content)
+	case BoneyardType:
+		if !opt.ShowBoneyard {
+			return ""
+		}
+		return createElement("div", []string{"boneyard"}, element.Content)
 	case PageFeed:
 		return createElement("hr", []string{"page-feed"}, "")
+	case DualDialogueType:
+		left, right := element.DualDialogueBlocks()
+		src := []string{}
+		for _, elem := range left {
+			src = append(src, elem.RenderHTML(opt))
+		}
+		out := []string{createElement("div", []string{"dual-dialogue-left"}, "\n"+strings.Join(src, ""))}
+		src = []string{}
+		for _, elem := range right {
+			src = append(src, elem.RenderHTML(opt))
+		}
+		out = append(out, createElement("div", []string{"dual-dialogue-right"}, "\n"+strings.Join(src, "")))
+		return createElement("div", []string{"dual-dialogue"}, "\n"+strings.Join(out, ""))
 	default:
-		return createElement("div", []string{strings.ToLower(strings.Replace(typeName(element.Type), " ", "-", -1))}, element.Content)
+		return createElement("div", []string{strings.ToLower(strings.Replace(typeName(element.Type), " ", "-", -1))}, element.htmlFor(opt, nil))
 	}
 }
 
 // String return a Fountain formatted document as a string
 func (doc *Fountain) String() string {
+	return doc.RenderString(nil)
+}
+
+// RenderString return a Fountain formatted document as a string using
+// the provided options. If opt is nil the package defaults are used.
+func (doc *Fountain) RenderString(opt *Options) string {
 	var s string
+	opt = options(opt)
 	src := []string{}
-	if doc.TitlePage != nil {
+	if len(doc.TitlePage) > 0 {
 		for _, elem := range doc.TitlePage {
-			s = elem.String()
+			s = elem.RenderString(opt)
 			src = append(src, s)
 		}
-		s = "\n"
-		src = append(src, s)
+		// A blank line ends the title page
+		if len(doc.Elements) == 0 || doc.Elements[0].Type != EmptyType {
+			src = append(src, "")
+		}
 	}
 	if doc.Elements != nil {
 		for _, elem := range doc.Elements {
 			switch elem.Type {
 			case NoteType:
-				if ShowNotes {
+				if opt.ShowNotes {
+					src = append(src, elem.Content)
+				}
+			case BoneyardType:
+				if opt.ShowBoneyard {
 					src = append(src, elem.Content)
 				}
 			case SectionType:
-				if ShowSection {
+				if opt.ShowSection {
 					src = append(src, elem.Content)
 				}
 			case SynopsisType:
-				if ShowSynopsis {
+				if opt.ShowSynopsis {
 					src = append(src, elem.Content)
 				}
 			default:
-				s = elem.String()
+				s = elem.RenderString(opt)
 				src = append(src, s)
 			}
 		}
@@ -499,20 +898,23 @@ func (doc *Fountain) String() string {
 	return strings.Join(src, "\n")
 }
 
+// isTitlePageKey returns true if the line starts a title page key.
+// Scene headings and transitions (e.g. "FADE IN:") are not keys.
+func isTitlePageKey(line string) bool {
+	return reTitlePageKey.MatchString(line) && !isSceneHeading(line, EmptyType) && !isTransition(line, EmptyType)
+}
+
 // isTitlePage evaluates the current line to see if we're still in the
-// title page element.
+// title page element. The first blank line ends the title page.
 func isTitlePage(line string, prevType int) bool {
-	if prevType == TitlePageType && isSceneHeading(line, prevType) == false && isTransition(line, prevType) == false {
+	if prevType == TitlePageType && strings.TrimSpace(line) != "" && isSceneHeading(line, prevType) == false && isTransition(line, prevType) == false {
 		return true
 	}
 	return false
 }
 
-// isEmpty evaluates the current line to see if we're an "empty" line or still in title page.
+// isEmpty evaluates the current line to see if we're an "empty" line.
 func isEmpty(line string, prevType int) bool {
-	if prevType == TitlePageType {
-		return false
-	}
 	if len(strings.TrimSpace(line)) == 0 {
 		return true
 	}
@@ -537,17 +939,10 @@ func isSceneHeading(line string, prevType int) bool {
 		return false
 	case reSceneNo.MatchString(line):
 		return true
-	case strings.HasPrefix(line, "."):
+	case reForcedHeading.MatchString(line):
 		return true
-	case strings.HasPrefix(line, "EXT"):
-		// We have line starting with EXT or EXT.
-		return true
-	case strings.HasPrefix(line, "INT"):
-		// We have line starting with including INT., INT./EXT, INT/EXT
-		return true
-	case strings.HasPrefix(line, "I/E"):
-		return true
-	case strings.Contains(line, " -"):
+	case reSetting.MatchString(line):
+		// We have line starting with INT., EXT., EST., INT./EXT, I/E
 		return true
 	case strings.Compare(line, "FADE IN:") == 0:
 		return true
@@ -588,7 +983,7 @@ func isAction(line string, prevType int) bool {
 	if strings.HasPrefix(line, "!") {
 		return true
 	}
-	if len(strings.TrimSpace(line)) == 0 {
+	if len(strings.TrimSpace(line)) == 0 || isForcedTransition(line) {
 		return false
 	}
 	if isSceneHeading(line, prevType) == false && isCharacter(line, prevType) == false && isDialogue(line, prevType) == false && isParenthetical(line, prevType) == false {
@@ -597,6 +992,13 @@ func isAction(line string, prevType int) bool {
 	return false
 }
 
+// isForcedTransition returns true if the line is a transition forced
+// with ">", centered text (e.g. ">THE END<") is not.
+func isForcedTransition(line string) bool {
+	line = strings.TrimSpace(line)
+	return strings.HasPrefix(line, ">") && !strings.HasSuffix(line, "<")
+}
+
 // isCharacter evaluates a prev, current and next lines and returns true if it looks like a Character or false otherwise
 //
 // FIXME: to really know that this is a character line we need
@@ -616,6 +1018,10 @@ func isCharacter(line string, prevType int) bool {
 		// not sufficient. The directives like `(O.S.)` should be
 		// trimmed from the name when evaluating name.
 
+		// A blank line is not a name
+		if content == "" {
+			return false
+		}
 		// If quotes are present then they are not a name.
 		if strings.HasPrefix(content, `"`) && strings.HasSuffix(content, `"`) {
 			return false
@@ -639,6 +1045,8 @@ func isCharacter(line string, prevType int) bool {
 // isParenthetical evaluates a prevType and current line
 // and returns true if it looks like a Character or false otherwise
 func isParenthetical(line string, prevType int) bool {
+	// NOTE: leading white space is ignored, e.g. indented by fountainfmt
+	line = strings.TrimSpace(line)
 	if strings.HasPrefix(line, "(") && strings.Contains(line, ")") {
 		return true
 	}
@@ -680,6 +1088,79 @@ func isTransition(line string, prevType int) bool {
 	return false
 }
 
+// forceMarker returns the Fountain marker needed so a line is read
+// back as the element type (e.g. "!" for action that looks like a
+// scene heading) when it follows an element of prevType. It returns
+// an empty string if the line is read as the type without one.
+func forceMarker(elemType int, line string, prevType int) string {
+	line = strings.TrimSpace(line)
+	marker := ""
+	switch elemType {
+	case SceneHeadingType:
+		marker = "."
+	case ActionType:
+		marker = "!"
+	case CharacterType:
+		marker = "@"
+	case TransitionType:
+		marker = ">"
+	case LyricType:
+		return "~"
+	default:
+		return ""
+	}
+	// Notes and boneyard shown in the line don't change its type
+	visible, _ := visibleText(line, "")
+	visible = strings.TrimSpace(visible)
+	if visible == "" || (!strings.HasPrefix(line, marker) && getLineType(visible, prevType) == elemType) {
+		return ""
+	}
+	return marker
+}
+
+// removeMarker removes the marker forcing the type of a line (e.g. "!"
+// for action). It returns the line unchanged and false if the line
+// isn't forced. Centered text (e.g. ">THE END<") keeps its markers.
+func removeMarker(elemType int, line string) (string, bool) {
+	s := strings.TrimLeft(line, " \t")
+	marker := ""
+	switch elemType {
+	case SceneHeadingType:
+		if reForcedHeading.MatchString(s) {
+			marker = "."
+		}
+	case ActionType:
+		marker = "!"
+	case CharacterType:
+		marker = "@"
+	case TransitionType:
+		if !strings.HasSuffix(strings.TrimSpace(s), "<") {
+			marker = ">"
+		}
+	case LyricType:
+		marker = "~"
+	}
+	if marker == "" || !strings.HasPrefix(s, marker) {
+		return line, false
+	}
+	return line[0:len(line)-len(s)] + s[len(marker):], true
+}
+
+// forceLines adds the markers needed so each line of text is read back
+// as the element type
+func forceLines(elemType int, text string) string {
+	lines := strings.Split(text, "\n")
+	prevType := EmptyType
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		lines[i] = forceMarker(elemType, line, prevType) + line
+		prevType = elemType
+	}
+	return strings.Join(lines, "\n")
+}
+
 // isLyric evaluates a line to see if it is a lyric.
 func isLyric(line string, prevType int) bool {
 	line = strings.TrimSpace(line)
@@ -816,145 +1297,50 @@ func getLineType(line string, prevType int) int {
 
 // Parse takes []byte and returns a Fountain struct and error
 func Parse(src []byte) (*Fountain, error) {
-	prevType := TitlePageType
-	key, value := "", ""
-	document := new(Fountain)
-	scanner := bufio.NewScanner(bytes.NewReader(src))
-	foundEndOfScript := false
-	for scanner.Scan() {
-		line := scanner.Text()
-		if !foundEndOfScript {
-			currentType := getLineType(line, prevType)
-			switch currentType {
-			case TitlePageType:
-				if strings.Contains(line, ":") {
-					parts := strings.SplitN(line, ":", 2)
-					key, value = parts[0], parts[1]
-					elem := new(Element)
-					elem.Type = TitlePageType
-					elem.Name = key
-					elem.Content = value
-					document.TitlePage = append(document.TitlePage, elem)
-				} else {
-					i := len(document.TitlePage) - 1
-					if i < 0 {
-						i = 0
-						elem := new(Element)
-						elem.Type = TitlePageType
-						elem.Name = "Unknown"
-						elem.Content = line
-						document.TitlePage = append(document.TitlePage, elem)
-					} else {
-						elem := document.TitlePage[i]
-						elem.Content = elem.Content + "\n" + line
-						document.TitlePage[i] = elem
-					}
-				}
-			default:
-				// If we haven't changed types we don't need to create
-				// a new element.
-				if prevType == currentType {
-					i := len(document.Elements) - 1
-					if i < 0 {
-						i = 0
-						elem := new(Element)
-						elem.Type = currentType
-						elem.Name = typeName(elem.Type)
-						elem.Content = line
-						document.Elements[i] = elem
-					} else {
-						elem := document.Elements[i]
-						elem.Name = typeName(elem.Type)
-						elem.Content = elem.Content + "\n" + line
-						document.Elements[i] = elem
-					}
-				} else {
-					element := new(Element)
-					element.Type = currentType
-					element.Name = typeName(element.Type)
-					element.Content = line
-					document.Elements = append(document.Elements, element)
-					if element.Type == SceneHeadingType {
-						foundEndOfScript = isEndOfScript(element)
-					}
-				}
-			}
-			prevType = currentType
-		} else {
-			element := new(Element)
-			element.Type = GeneralTextType
-			element.Name = typeName(element.Type)
-			element.Content = line
-			document.Elements = append(document.Elements, element)
-		}
-	}
-	if err := scanner.Err(); err != nil {
-		return document, err
-	}
-	// NOTE: Character name lines required look ahead.
-	// I need to cleanup miss identified Character elements by
-	// applying dialaog is next element rule.
-	lastElement := len(document.Elements) - 1
-	prevElementType := TitlePageType
-	for i, element := range document.Elements {
-		// Have we identified the character type correctly?
-		if element.Type == CharacterType {
-			if prevElementType == EmptyType {
-				if i < lastElement {
-					nextElementType := document.Elements[i+1].Type
-					if !(nextElementType == DialogueType || nextElementType == ParentheticalType) {
-						// What type are we?
-						element.Type = GeneralTextType
-					}
-				}
-				// NOTE: Character must be followed by dialog or
-				// parenthetical but the last element has been identified
-				// as a character element, what should this element be?
-				// We may just have an imcomplete script.
-			}
-		}
-		// If we're at the end of the script then we zero more characters.
-		if element.Type == SceneHeadingType && isEndOfScript(element) {
-			break
-		}
-		prevElementType = element.Type
-	}
-	return document, nil
+	return NewDecoder(bytes.NewReader(src)).Decode()
 }
 
 // ParseFile takes a filename and returns a Fountain struct and error
 func ParseFile(fname string) (*Fountain, error) {
-	src, err := ioutil.ReadFile(fname)
+	in, err := os.Open(fname)
 	if err != nil {
 		return nil, err
 	}
-	return Parse(src)
+	defer in.Close()
+	return NewDecoder(in).Decode()
 }
 
-// ToHTML converts a Fountain document based on the Options prvided.
-// @param opt *Options a populate struct of options this package supports
+// ToHTML converts a Fountain document to HTML using the package defaults.
 // @return string of HTML
 func (doc *Fountain) ToHTML() string {
+	return doc.RenderHTML(nil)
+}
+
+// RenderHTML converts a Fountain document based on the Options prvided.
+// @param opt *Options a populate struct of options this package supports
+// @return string of HTML
+func (doc *Fountain) RenderHTML(opt *Options) string {
 	var err error
+	opt = options(opt)
 	out := []string{}
 	// Handle Opening .AsHTMLPage
 	src := ""
-	if AsHTMLPage {
-		if LinkCSS {
-			src, err = getCSSLink()
+	if opt.AsHTMLPage {
+		if opt.LinkCSS {
+			src, err = getCSSLink(opt.CSS)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "WARNING: %s\n", err)
 			}
 		}
-		if InlineCSS {
-			src, err = getCSS()
+		if opt.InlineCSS {
+			src, err = getCSS(opt.CSS)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "WARNING: %s, using default CSS\n", err)
 				// Fallback to default CSS after printing warning.
 				src = createElement("style", []string{}, SourceCSS)
 			}
 		}
-		if LinkCSS || InlineCSS {
+		if opt.LinkCSS || opt.InlineCSS {
 			out = append(out, fmt.Sprintf(`<!DOCTYPE html>
 <html>
 	<head>
@@ -970,15 +1356,15 @@ func (doc *Fountain) ToHTML() string {
 `, src)
 		}
 	} else {
-		if LinkCSS {
-			src, err = getCSSLink()
+		if opt.LinkCSS {
+			src, err = getCSSLink(opt.CSS)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "WARNING: %s\n", err)
 			}
 			out = append(out, src)
 		}
-		if InlineCSS {
-			src, err = getCSS()
+		if opt.InlineCSS {
+			src, err = getCSS(opt.CSS)
 			if err != nil {
 				log.Printf("%s", err)
 			} else {
@@ -987,12 +1373,10 @@ func (doc *Fountain) ToHTML() string {
 		}
 		out = append(out, fmt.Sprintf("<section class=%q>\n", "fountain"))
 	}
-	if doc.TitlePage != nil {
+	if len(doc.TitlePage) > 0 {
 		out = append(out, `<section class="title-page">
 `)
-		for _, elem := range doc.TitlePage {
-			out = append(out, elem.ToHTML())
-		}
+		out = append(out, ParseTitlePage(doc.TitlePage).RenderHTML())
 		out = append(out, `</section>
 `)
 	}
@@ -1000,14 +1384,14 @@ func (doc *Fountain) ToHTML() string {
 		out = append(out, `<section class="script">
 `)
 		for _, elem := range doc.Elements {
-			out = append(out, elem.ToHTML())
+			out = append(out, elem.RenderHTML(opt))
 		}
 		out = append(out, `</section>
 `)
 	}
 
 	// Handle Closing .AsHTMLPage
-	if AsHTMLPage {
+	if opt.AsHTMLPage {
 		out = append(out, `
         </section>
 	</body>
@@ -1022,26 +1406,124 @@ func (doc *Fountain) ToHTML() string {
 // ToJSON renders a Fountain type documents into a JSON
 // serialized data structure.
 func (doc *Fountain) ToJSON() ([]byte, error) {
-	if PrettyPrint {
+	return doc.RenderJSON(nil)
+}
+
+// RenderJSON renders a Fountain type documents into a JSON
+// serialized data structure using the provided options.
+// If opt is nil the package defaults are used.
+func (doc *Fountain) RenderJSON(opt *Options) ([]byte, error) {
+	opt = options(opt)
+	if opt.PrettyPrint {
 		return json.MarshalIndent(doc, "", "    ")
 	}
 	return json.Marshal(doc)
 }
 
 // ToYAML renders a Fountain type document into a YAML serialized data structure.
+// The document has the same shape as the one written by ToJSON.
 func (doc *Fountain) ToYAML() ([]byte, error) {
-	src := []byte{}
-	buf := bytes.NewBuffer(src)
+	// NOTE: yaml.v3 writes multi-line strings as literal blocks and
+	// can't read back a block starting with a newline or tab (e.g. a
+	// multi-line title page value). JSON is YAML so the document is
+	// read from its JSON into nodes where those strings are quoted.
+	src, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	node := new(yaml.Node)
+	if err := yaml.Unmarshal(src, node); err != nil {
+		return nil, err
+	}
+	setYAMLStyle(node)
+	buf := new(bytes.Buffer)
 	encoder := yaml.NewEncoder(buf)
 	encoder.SetIndent(2)
-	err := encoder.Encode(doc)
-	return src, err
+	err = encoder.Encode(node)
+	encoder.Close()
+	return buf.Bytes(), err
+}
+
+// setYAMLStyle clears the JSON quoting and flow style from the nodes
+// except for multi-line strings starting with white space, those stay
+// double quoted.
+func setYAMLStyle(node *yaml.Node) {
+	node.Style = 0
+	if node.Kind == yaml.ScalarNode && strings.Contains(node.Value, "\n") && strings.TrimLeft(node.Value, " \t\n") != node.Value {
+		node.Style = yaml.DoubleQuotedStyle
+	}
+	for _, child := range node.Content {
+		setYAMLStyle(child)
+	}
+}
+
+// prepare brings decoded elements back to the state Parse leaves them
+// in. Content is taken as the source of truth (it may have been edited)
+// so trailing scene numbers are moved into SceneNumber and the emphasis
+// spans are parsed again.
+func prepare(elements []*Element) error {
+	for i, element := range elements {
+		if element == nil {
+			return fmt.Errorf("element %d is empty", i)
+		}
+		if element.Type < GeneralTextType || element.Type > DualDialogueType {
+			return fmt.Errorf("element %d has an unknown type %d", i, element.Type)
+		}
+		if element.Annotations == nil {
+			element.parseAnnotations()
+		}
+		element.parseSceneNumber()
+		element.parseDepth()
+		element.parseSpans()
+		if err := prepare(element.Elements); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FromJSON decodes a Fountain document written by ToJSON (e.g. after
+// editing it as structured data). The result can be written back to
+// Fountain markup with String().
+func FromJSON(src []byte) (*Fountain, error) {
+	doc := new(Fountain)
+	if err := json.Unmarshal(src, doc); err != nil {
+		return nil, err
+	}
+	if err := prepare(doc.TitlePage); err != nil {
+		return nil, err
+	}
+	if err := prepare(doc.Elements); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// FromYAML decodes a Fountain document written by ToYAML.
+func FromYAML(src []byte) (*Fountain, error) {
+	doc := new(Fountain)
+	if err := yaml.Unmarshal(src, doc); err != nil {
+		return nil, err
+	}
+	if err := prepare(doc.TitlePage); err != nil {
+		return nil, err
+	}
+	if err := prepare(doc.Elements); err != nil {
+		return nil, err
+	}
+	return doc, nil
 }
 
 // Run takes a byte split and returns an HTML fragment appropriate
 // to use as a Scrippet with John Augusts' CSS
 // https://fountain.io/_css/scrippets.css
 func Run(input []byte) ([]byte, error) {
+	return RunWithOptions(input, nil)
+}
+
+// RunWithOptions is like Run but renders the HTML fragment using the
+// provided options. If opt is nil the package defaults are used.
+func RunWithOptions(input []byte, opt *Options) ([]byte, error) {
 	var (
 		out []byte
 	)
@@ -1049,7 +1531,7 @@ func Run(input []byte) ([]byte, error) {
 	if err != nil {
 		out = append(out, input...)
 	} else {
-		out = append(out, []byte(doc.ToHTML())...)
+		out = append(out, []byte(doc.RenderHTML(opt))...)
 	}
 	return out, err
 }
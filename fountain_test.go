@@ -31,6 +31,7 @@ package fountain
 
 import (
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path"
@@ -58,6 +59,7 @@ And you fetch the tongs.
 `)
 
 	expected := []int{
+		EmptyType,         // (a blank first line, there is no title page)
 		SceneHeadingType,  // INT. LAB - DAY
 		EmptyType,         //
 		CharacterType,     // CHARLIE
@@ -142,6 +144,36 @@ func TestTypes(t *testing.T) {
 
 }
 
+func TestIsSceneHeading(t *testing.T) {
+	for line, expected := range map[string]bool{
+		"INT. HOUSE - DAY":      true,
+		"INT.HOUSE - DAY":       true,
+		"ext.yard":              true,
+		"INT HOUSE - DAY":       true,
+		"INT./EXT. CAR - NIGHT": true,
+		"INT/EXT CAR - NIGHT":   true,
+		"I/E CAR - NIGHT":       true,
+		"EST. CITY - DAWN":      true,
+		".FLASHBACK":            true,
+		"INT.":                  true,
+		"INTO THE NIGHT":        false,
+		"EXTRA! EXTRA!":         false,
+		"INTERIOR DESIGN":       false,
+		"Bob runs - fast":       false,
+		"THE HOUSE - LATER":     false,
+		"!INT. HOUSE - DAY":     false,
+		"...and then":           false,
+	} {
+		if got := isSceneHeading(line, EmptyType); got != expected {
+			t.Errorf("isSceneHeading(%q) expected %t, got %t", line, expected, got)
+		}
+	}
+	scene := ParseSceneHeading(&Element{Type: SceneHeadingType, Content: "INT.HOUSE - DAY"})
+	if scene == nil || scene.Setting != "INT" || scene.Location != "HOUSE" || scene.TimeOfDay != "DAY" {
+		t.Errorf("expected INT, HOUSE and DAY, got %+v", scene)
+	}
+}
+
 func TestSamples(t *testing.T) {
 	files := []string{
 		"sample-01.fountain",
@@ -212,6 +244,478 @@ func TestIssue2Scripttool(t *testing.T) {
 	}
 }
 
+func TestRenderOptions(t *testing.T) {
+	src := []byte(`
+INT. LAB - DAY
+
+[[Check the lighting]]
+
+CHARLIE
+Bring that to me.
+`)
+	doc, err := Parse(src)
+	assertOK(t, err, "Parse(src)")
+
+	withNotes := DefaultOptions()
+	withNotes.ShowNotes = true
+	withoutNotes := DefaultOptions()
+	withoutNotes.ShowNotes = false
+
+	done := make(chan string, 2)
+	go func() { done <- doc.RenderString(withNotes) }()
+	go func() { done <- doc.RenderString(withoutNotes) }()
+	notesFound := 0
+	for i := 0; i < 2; i++ {
+		if strings.Contains(<-done, "[[Check the lighting]]") {
+			notesFound++
+		}
+	}
+	if notesFound != 1 {
+		t.Errorf("expected notes in exactly one rendering, got %d", notesFound)
+	}
+
+	pretty := DefaultOptions()
+	pretty.PrettyPrint = true
+	src, err = doc.RenderJSON(pretty)
+	assertOK(t, err, "doc.RenderJSON(pretty)")
+	if !strings.Contains(string(src), "\n    ") {
+		t.Errorf("expected indented JSON, got %s", src)
+	}
+	if PrettyPrint {
+		t.Errorf("RenderJSON should not change the package level PrettyPrint")
+	}
+}
+
+func TestEmphasis(t *testing.T) {
+	testCases := map[string]string{
+		"*italic*":                        "<em>italic</em>",
+		"**bold**":                        "<strong>bold</strong>",
+		"***bold italic***":               "<strong><em>bold italic</em></strong>",
+		"_underline_":                     "<u>underline</u>",
+		"*italic **bold** italic*":        "<em>italic <strong>bold</strong> italic</em>",
+		"**bold *italic***":               "<strong>bold <em>italic</em></strong>",
+		`\*not italic\*`:                  "*not italic*",
+		"5 * 3 is fifteen":                "5 * 3 is fifteen",
+		"*no emphasis\nacross lines*":     "*no emphasis\nacross lines*",
+		"_*underlined italic*_ and plain": "<u><em>underlined italic</em></u> and plain",
+		`Open C:\path and *run*.`:         `Open C:\path and <em>run</em>.`,
+		`\\*not italic\\*`:                `\*not italic\*`,
+	}
+	for src, expected := range testCases {
+		spans := ParseEmphasis(src)
+		if got := spansToHTML(spans, nil); got != expected {
+			t.Errorf("expected %q, got %q for %q", expected, got, src)
+		}
+		// After a heading so "Open C:" isn't read as a title page key
+		doc, err := Parse([]byte("INT. LAB - DAY\n\n" + src + "\n"))
+		assertOK(t, err, "Parse(src)")
+		if action := doc.Elements[2]; action.Type != ActionType || action.String() != src {
+			t.Errorf("expected round trip %q, got %s %q", src, action.TypeName(), action.String())
+		}
+	}
+
+	doc, err := Parse([]byte(`
+INT. LAB - DAY
+
+CHARLIE
+I *really* need the **tongs**.
+`))
+	assertOK(t, err, "Parse(src)")
+	found := false
+	for _, elem := range doc.Elements {
+		if elem.Type == DialogueType {
+			found = true
+			if got := elem.ToHTML(); !strings.Contains(got, "I <em>really</em> need the <strong>tongs</strong>.") {
+				t.Errorf("expected emphasis in HTML, got %q", got)
+			}
+			if got := elem.String(); !strings.Contains(got, "I *really* need the **tongs**.") {
+				t.Errorf("expected emphasis markers in String(), got %q", got)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("expected a dialogue element, %+v", doc.Elements)
+	}
+}
+
+func TestDualDialogue(t *testing.T) {
+	src := []byte(`
+INT. KITCHEN - NIGHT
+
+BRICK
+Screw retirement.
+
+STEEL ^
+(shouting)
+Screw retirement.
+
+They look at each other.
+`)
+	expected := []int{
+		EmptyType,
+		SceneHeadingType,
+		EmptyType,
+		DualDialogueType,
+		EmptyType,
+		ActionType,
+	}
+	doc, err := Parse(src)
+	assertOK(t, err, "Parse(src)")
+	if len(doc.Elements) != len(expected) {
+		for _, elem := range doc.Elements {
+			t.Logf("%s %q", elem.TypeName(), elem.Content)
+		}
+		t.Fatalf("expected %d elements, got %d", len(expected), len(doc.Elements))
+	}
+	for i, elem := range doc.Elements {
+		if elem.Type != expected[i] {
+			t.Errorf("(%d) expected %q, got %q for %q", i, typeName(expected[i]), elem.TypeName(), elem.Content)
+		}
+	}
+	left, right := doc.Elements[3].DualDialogueBlocks()
+	if len(left) != 2 || len(right) != 3 {
+		t.Fatalf("expected 2 left and 3 right elements, got %d and %d", len(left), len(right))
+	}
+	if name := CharacterName(left[0]); name != "BRICK" {
+		t.Errorf("expected BRICK, got %q", name)
+	}
+	if name := CharacterName(right[0]); name != "STEEL" {
+		t.Errorf("expected STEEL, got %q", name)
+	}
+	if s := doc.String(); !strings.Contains(s, "STEEL ^") {
+		t.Errorf("expected caret to be written back, got %s", s)
+	}
+	if s := doc.ToHTML(); !strings.Contains(s, `class="dual-dialogue"`) || !strings.Contains(s, `class="dual-dialogue-right"`) {
+		t.Errorf("expected dual dialogue HTML, got %s", s)
+	}
+}
+
+func TestSceneNumbers(t *testing.T) {
+	src := []byte(`
+FADE IN:
+
+INT. HOUSE - DAY #1A#
+
+Tom enters.
+
+EXT. GARDEN - DAY #I-1-A#
+
+Tom exits.
+
+EXT. STREET - NIGHT
+
+Tom runs.
+`)
+	doc, err := Parse(src)
+	assertOK(t, err, "Parse(src)")
+	scenes := []*Element{}
+	for _, elem := range doc.Elements {
+		if isScene(elem) {
+			scenes = append(scenes, elem)
+		}
+	}
+	if len(scenes) != 3 {
+		t.Fatalf("expected 3 scenes, got %d", len(scenes))
+	}
+	expected := []string{"1A", "I-1-A", ""}
+	for i, scene := range scenes {
+		if scene.SceneNumber != expected[i] {
+			t.Errorf("(%d) expected scene number %q, got %q", i, expected[i], scene.SceneNumber)
+		}
+		if strings.Contains(scene.Content, "#") {
+			t.Errorf("(%d) expected scene number removed from content, got %q", i, scene.Content)
+		}
+	}
+	if s := scenes[0].ToHTML(); !strings.Contains(s, `<span class="scene-number-left">1A</span>`) || !strings.Contains(s, `<span class="scene-number-right">1A</span>`) {
+		t.Errorf("expected scene numbers in both margins, got %s", s)
+	}
+	if s := scenes[0].String(); s != "INT. HOUSE - DAY #1A#" {
+		t.Errorf("expected scene number written back, got %q", s)
+	}
+	src, err = doc.ToJSON()
+	assertOK(t, err, "doc.ToJSON()")
+	if !strings.Contains(string(src), `"scene_number":"I-1-A"`) {
+		t.Errorf("expected scene_number in JSON, got %s", src)
+	}
+
+	doc.NumberScenes(false)
+	if scenes[2].SceneNumber != "I-1-AA" {
+		t.Errorf("expected inserted scene number I-1-AA, got %q", scenes[2].SceneNumber)
+	}
+	doc.NumberScenes(true)
+	for i, scene := range scenes {
+		if scene.SceneNumber != fmt.Sprintf("%d", i+1) {
+			t.Errorf("(%d) expected scene renumbered to %d, got %q", i, i+1, scene.SceneNumber)
+		}
+	}
+}
+
+func TestNumberScenesTaken(t *testing.T) {
+	src := []byte(`INT. ATTIC - DAY
+
+INT. HOUSE - DAY #5#
+
+EXT. GARDEN - DAY
+
+EXT. YARD - DAY
+
+EXT. STREET - NIGHT #5A#
+
+EXT. PARK - DAY
+
+EXT. ROAD - NIGHT #A5#
+
+EXT. LANE - NIGHT #5c#
+`)
+	doc, err := Parse(src)
+	assertOK(t, err, "Parse(src)")
+	doc.NumberScenes(false)
+	numbers := []string{}
+	for _, elem := range doc.Elements {
+		if isScene(elem) {
+			numbers = append(numbers, elem.SceneNumber)
+		}
+	}
+	// The numbers already in use (A5, 5A and 5c) are skipped
+	if got := strings.Join(numbers, ","); got != "B5,5,5B,5D,5A,5AA,A5,5c" {
+		t.Errorf("expected B5,5,5B,5D,5A,5AA,A5,5c, got %s", got)
+	}
+}
+
+func TestForcedMarkers(t *testing.T) {
+	src := []byte(`!FADE IN:
+
+INT. HOUSE - DAY
+
+...and then nothing.
+
+.flashback
+
+@McCLANE
+Yippee.
+
+!BOOM.
+
+>CUT TO:
+
+>FADE OUT.
+
+~La la la
+~Do re mi
+
+He waits.
+!INT. NOT A HEADING
+
+>THE END<
+`)
+	expected := []struct {
+		Type    int
+		Content string
+		Forced  bool
+	}{
+		{ActionType, "FADE IN:", true},
+		{SceneHeadingType, "INT. HOUSE - DAY", false},
+		{ActionType, "...and then nothing.", false},
+		{SceneHeadingType, "flashback", true},
+		{CharacterType, "McCLANE", true},
+		{DialogueType, "Yippee.", false},
+		{ActionType, "BOOM.", true},
+		{TransitionType, "CUT TO:", true},
+		{TransitionType, "FADE OUT.", true},
+		{LyricType, "La la la\nDo re mi", true},
+		{ActionType, "He waits.\nINT. NOT A HEADING", true},
+		{TransitionType, ">THE END<", false},
+	}
+	check := func(doc *Fountain, reparsed bool) {
+		elements := []*Element{}
+		for _, element := range doc.Elements {
+			if element.Type != EmptyType {
+				elements = append(elements, element)
+			}
+		}
+		if len(elements) != len(expected) {
+			t.Fatalf("expected %d elements, got %d", len(expected), len(elements))
+		}
+		for i, e := range expected {
+			if reparsed {
+				// The pretty printer pads and upper cases the text
+				if elements[i].Type != e.Type || !strings.EqualFold(strings.TrimSpace(elements[i].Content), e.Content) {
+					t.Errorf("element %d: expected %s %q, got %s %q", i, typeName(e.Type), e.Content, elements[i].TypeName(), elements[i].Content)
+				}
+			} else if elements[i].Type != e.Type || elements[i].Content != e.Content || elements[i].Forced != e.Forced {
+				t.Errorf("element %d: expected %s %q (forced %t), got %s %q (forced %t)", i, typeName(e.Type), e.Content, e.Forced, elements[i].TypeName(), elements[i].Content, elements[i].Forced)
+			}
+		}
+	}
+	doc, err := Parse(src)
+	assertOK(t, err, "Parse(src)")
+	check(doc, false)
+
+	// Markers are only written where they are needed
+	s := doc.String()
+	for _, expected := range []string{"!FADE IN:\n", "\nINT. HOUSE - DAY\n", "\n.FLASHBACK\n", "\n!BOOM.\n", " >CUT TO:\n", " >FADE OUT.\n", "~La la la\n~Do re mi\n", "\nHe waits.\n!INT. NOT A HEADING\n"} {
+		if !strings.Contains(s, expected) {
+			t.Errorf("expected %q in\n%s", expected, s)
+		}
+	}
+	if strings.Contains(s, "@") || strings.Contains(s, "!He") {
+		t.Errorf("expected no unneeded markers in\n%s", s)
+	}
+	doc, err = Parse([]byte(s))
+	assertOK(t, err, "Parse(doc.String())")
+	check(doc, true)
+
+	// Renderers don't print the markers
+	html := doc.ToHTML()
+	for _, marker := range []string{">!", ">@", ">.FLASH", ">~", ">>FADE"} {
+		if strings.Contains(html, marker) {
+			t.Errorf("expected no %q in\n%s", marker, html)
+		}
+	}
+}
+
+func TestPositions(t *testing.T) {
+	src := []byte("Title: Test\r\nAuthor: Me\r\n\r\nINT. LAB - DAY\r\n\r\nCHARLIE\r\n(turns)\r\nBring that to me.\r\n")
+	doc, err := Parse(src)
+	assertOK(t, err, "Parse(src)")
+	for _, elem := range append(doc.TitlePage, doc.Elements...) {
+		if elem.Start == nil || elem.End == nil {
+			t.Fatalf("expected positions for %s %q", elem.TypeName(), elem.Content)
+		}
+		if elem.Type == EmptyType {
+			continue
+		}
+		got := string(src[elem.Start.Offset:elem.End.Offset])
+		if !strings.Contains(got, strings.TrimSpace(elem.Content)) {
+			t.Errorf("expected source %q to hold %q", got, elem.Content)
+		}
+	}
+	dialogue := doc.Elements[len(doc.Elements)-1]
+	if dialogue.Type != DialogueType {
+		t.Fatalf("expected dialogue, got %s %q", dialogue.TypeName(), dialogue.Content)
+	}
+	if dialogue.Start.Line != 8 || dialogue.End.Line != 8 || dialogue.End.Column != 18 {
+		t.Errorf("expected dialogue at 8:1-8:18, got %s-%s", dialogue.Start, dialogue.End)
+	}
+	if author := doc.TitlePage[1]; author.Start.Line != 2 || author.Start.Offset != 13 {
+		t.Errorf("expected author at line 2 offset 13, got %+v", author.Start)
+	}
+}
+
+func TestDecoder(t *testing.T) {
+	r, w := io.Pipe()
+	decoder := NewDecoder(r)
+	go func() {
+		fmt.Fprintf(w, "Title: Streaming\n\nINT. LAB - DAY\n\nThe lights flicker.\n\n")
+	}()
+	// Elements are returned before the input is closed
+	expected := []int{TitlePageType, EmptyType, SceneHeadingType, EmptyType, ActionType}
+	for i, elemType := range expected {
+		element, err := decoder.Next()
+		assertOK(t, err, "decoder.Next()")
+		if element.Type != elemType {
+			t.Errorf("(%d) expected %q, got %q for %q", i, typeName(elemType), element.TypeName(), element.Content)
+		}
+	}
+	go func() {
+		fmt.Fprintf(w, "CHARLIE\nBring that to me.\n")
+		w.Close()
+	}()
+	expected = []int{EmptyType, CharacterType, DialogueType}
+	for i, elemType := range expected {
+		element, err := decoder.Next()
+		assertOK(t, err, "decoder.Next()")
+		if element.Type != elemType {
+			t.Errorf("(%d) expected %q, got %q for %q", i, typeName(elemType), element.TypeName(), element.Content)
+		}
+	}
+	if _, err := decoder.Next(); err != io.EOF {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+}
+
+func TestFromJSONYAML(t *testing.T) {
+	for i := 1; i <= 7; i++ {
+		fname := path.Join("testdata", fmt.Sprintf("sample-%02d.fountain", i))
+		doc, err := ParseFile(fname)
+		assertOK(t, err, "ParseFile("+fname+")")
+		expected := doc.String()
+		src, err := doc.ToJSON()
+		assertOK(t, err, "ToJSON()")
+		fromJSON, err := FromJSON(src)
+		assertOK(t, err, "FromJSON("+fname+")")
+		if got := fromJSON.String(); got != expected {
+			t.Errorf("%s: expected the same Fountain back from JSON, got\n%s", fname, got)
+		}
+		src, err = doc.ToYAML()
+		assertOK(t, err, "ToYAML()")
+		if len(src) == 0 {
+			t.Fatalf("%s: expected YAML, got nothing", fname)
+		}
+		fromYAML, err := FromYAML(src)
+		assertOK(t, err, "FromYAML("+fname+")")
+		if got := fromYAML.String(); got != expected {
+			t.Errorf("%s: expected the same Fountain back from YAML, got\n%s", fname, got)
+		}
+	}
+
+	// Content wins over stale spans and scene numbers after an edit
+	src := []byte(`{"TitlePage":[{"type":2,"name":"Title","content":"Edited"}],
+"Elements":[{"type":3,"content":"INT. LAB - NIGHT #2#","scene_number":"1"},
+{"type":4,"content":"The *lights* go out.","spans":[{"text":"The lights flicker."}]}]}`)
+	doc, err := FromJSON(src)
+	assertOK(t, err, "FromJSON(src)")
+	if scene := doc.Elements[0]; scene.SceneNumber != "2" || scene.Content != "INT. LAB - NIGHT" {
+		t.Errorf("expected scene 2, got %+v", scene)
+	}
+	if s := doc.Elements[1].String(); s != "The *lights* go out." {
+		t.Errorf("expected the edited action, got %q", s)
+	}
+	if _, err := FromJSON([]byte(`{"Elements":[{"type":99,"content":"?"}]}`)); err == nil {
+		t.Errorf("expected an error for an unknown element type")
+	}
+}
+
+func TestFormatTwice(t *testing.T) {
+	opt := DefaultOptions()
+	opt.MaxWidth = 65
+	opt.ShowNotes = true
+	opt.ShowBoneyard = true
+	// types returns the element types, the text they hold is padded,
+	// wrapped and upper cased by the pretty printer
+	types := func(doc *Fountain) []string {
+		names := []string{}
+		for _, element := range doc.Elements {
+			if element.Type != EmptyType {
+				names = append(names, element.TypeName())
+			}
+		}
+		return names
+	}
+	for i := 1; i <= 7; i++ {
+		fname := path.Join("testdata", fmt.Sprintf("sample-%02d.fountain", i))
+		doc, err := ParseFile(fname)
+		assertOK(t, err, "ParseFile("+fname+")")
+		formatted := doc.RenderString(opt) + "\n"
+		doc2, err := Parse([]byte(formatted))
+		assertOK(t, err, "Parse(formatted)")
+		if got := doc2.RenderString(opt) + "\n"; got != formatted {
+			t.Errorf("%s: expected formatting again to change nothing, got\n%s", fname, got)
+		}
+		expected, got := types(doc), types(doc2)
+		if len(got) != len(expected) {
+			t.Errorf("%s: expected %d elements back, got %d", fname, len(expected), len(got))
+			continue
+		}
+		for j := range expected {
+			if got[j] != expected[j] {
+				t.Errorf("%s: element %d: expected %s back, got %s", fname, j, expected[j], got[j])
+				break
+			}
+		}
+	}
+}
+
 func TestMain(m *testing.M) {
 	// Setup everything, process flags, etc.
 	os.Exit(m.Run())
@@ -2,4 +2,4 @@ module github.com/rsdoiel/fountain
 
 go 1.22.0
 
-require gopkg.in/yaml.v3 v3.0.1 // indirect
+require gopkg.in/yaml.v3 v3.0.1
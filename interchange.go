@@ -0,0 +1,255 @@
+// Package fountain is a Golang package supporting Fountain screenplay markup.
+//
+// interchange.go holds the helpers shared by the readers and writers of
+// other screenplay formats (e.g. Final Draft and Open Screenplay Format).
+package fountain
+
+import (
+	"regexp"
+	"strings"
+)
+
+// reCredit matches the credit line of a title page (e.g. "Written by")
+var reCredit = regexp.MustCompile(`(?i)^((written|screenplay|story|teleplay)\s+)?by$`)
+
+// styledSpan returns text as a span wrapped in the given styles
+func styledSpan(text string, bold bool, italic bool, underline bool) *Span {
+	span := &Span{Text: text}
+	if italic {
+		span = &Span{Style: ItalicStyle, Spans: []*Span{span}}
+	}
+	if bold {
+		span = &Span{Style: BoldStyle, Spans: []*Span{span}}
+	}
+	if underline {
+		span = &Span{Style: UnderlineStyle, Spans: []*Span{span}}
+	}
+	return span
+}
+
+// importedElement creates an element from the styled text of a
+// paragraph, it returns nil for empty paragraphs.
+func importedElement(elemType int, centered bool, spans []*Span, sceneNumber string) *Element {
+	if len(spans) == 0 {
+		return nil
+	}
+	if elemType == ActionType && centered {
+		elemType = CenterAlignment
+	}
+	element := new(Element)
+	element.Type = elemType
+	element.Name = typeName(elemType)
+	switch elemType {
+	case SectionType:
+		element.Content = "# " + spansText(spans)
+		element.Depth = 1
+	case CenterAlignment:
+		element.Content = ">" + spansToFountain(spans) + "<"
+	default:
+		element.Content = spansToFountain(spans)
+		line := strings.SplitN(spansText(spans), "\n", 2)[0]
+		element.Forced = forceMarker(elemType, line, EmptyType) != ""
+	}
+	if elemType == SceneHeadingType {
+		element.SceneNumber = sceneNumber
+	}
+	element.parseSpans()
+	return element
+}
+
+// appendElement adds an element to the script separating it from the
+// previous element with an EmptyType element unless it continues a
+// character's speech.
+func appendElement(elements []*Element, element *Element) []*Element {
+	if len(elements) > 0 && !isSpeech(element) {
+		empty := new(Element)
+		empty.Type = EmptyType
+		empty.Name = typeName(EmptyType)
+		elements = append(elements, empty)
+	}
+	return append(elements, element)
+}
+
+// newPageFeed returns a PageFeed element
+func newPageFeed() *Element {
+	feed := new(Element)
+	feed.Type = PageFeed
+	feed.Name = typeName(PageFeed)
+	feed.Content = "==="
+	return feed
+}
+
+// titlePageElement creates a title page element for key and value.
+// Multi-line values are indented on the lines following the key.
+func titlePageElement(key string, value string) *Element {
+	element := new(Element)
+	element.Type = TitlePageType
+	element.Name = key
+	if strings.Contains(value, "\n") {
+		element.Content = "\n\t" + strings.Join(strings.Split(value, "\n"), "\n\t")
+	} else {
+		element.Content = " " + value
+	}
+	return element
+}
+
+// alignedText is a paragraph of a title page laid out by alignment
+// ("left", "center" or "right") instead of by key.
+type alignedText struct {
+	align string
+	style int
+	text  string
+}
+
+// inferTitlePage maps free form title page paragraphs to title page
+// keys. Only the layout is recorded so the keys are inferred, centered
+// text is the title, credit and author(s), text on the left or right
+// holds the copyright, draft date and contact.
+func inferTitlePage(paragraphs []*alignedText) []*Element {
+	centered, other := []string{}, []string{}
+	copyright, draftDate := "", ""
+	for _, p := range paragraphs {
+		value := strings.TrimSpace(p.text)
+		if value == "" {
+			continue
+		}
+		lower := strings.ToLower(value)
+		switch {
+		case p.align == "center":
+			centered = append(centered, value)
+		case strings.Contains(lower, "copyright") || strings.Contains(lower, "(c)") || strings.Contains(value, "©"):
+			copyright = value
+		case p.align == "right" && draftDate == "":
+			draftDate = value
+		default:
+			other = append(other, value)
+		}
+	}
+	elements := []*Element{}
+	authors := []string{}
+	for i, value := range centered {
+		switch {
+		case i == 0:
+			elements = append(elements, titlePageElement("Title", value))
+		case reCredit.MatchString(value):
+			elements = append(elements, titlePageElement("Credit", value))
+		case strings.HasPrefix(strings.ToLower(value), "based on"):
+			elements = append(elements, titlePageElement("Source", value))
+		default:
+			authors = append(authors, value)
+		}
+	}
+	if len(authors) > 0 {
+		elements = append(elements, titlePageElement("Author", strings.Join(authors, "\n")))
+	}
+	if draftDate == "" && len(other) > 1 {
+		draftDate, other = other[0], other[1:]
+	}
+	if draftDate != "" {
+		elements = append(elements, titlePageElement("Draft date", draftDate))
+	}
+	if copyright != "" {
+		elements = append(elements, titlePageElement("Copyright", copyright))
+	}
+	if len(other) > 0 {
+		elements = append(elements, titlePageElement("Contact", strings.Join(other, "\n")))
+	}
+	return elements
+}
+
+// titlePageLayout lays out the title page elements the way screenwriting
+// applications do, title, credit, author(s) and source centered, the
+// draft date and notes on the right and the copyright, contact details
+// and other keys on the left, as in the HTML and PDF title pages. Blank
+// paragraphs are used for spacing.
+func titlePageLayout(titlePage []*Element) []*alignedText {
+	values := map[string]string{}
+	keys := []string{}
+	for _, element := range titlePage {
+		key := titlePageKey(element.Name)
+		lines := []string{}
+		for _, line := range titlePageValue(element) {
+			lines = append(lines, string(toRunes(line)))
+		}
+		if _, ok := values[key]; !ok {
+			keys = append(keys, key)
+		}
+		values[key] = appendValue(values[key], strings.Join(lines, "\n"))
+	}
+	paragraphs := []*alignedText{}
+	add := func(align string, style int, value string) {
+		if value != "" {
+			paragraphs = append(paragraphs, &alignedText{align: align, style: style, text: value})
+		}
+	}
+	space := func(align string, n int) {
+		for i := 0; i < n; i++ {
+			paragraphs = append(paragraphs, &alignedText{align: align})
+		}
+	}
+	space("left", 16)
+	add("center", runUnderline, values["title"])
+	space("center", 3)
+	add("center", 0, values["credit"])
+	space("center", 1)
+	add("center", 0, values["author"])
+	space("center", 1)
+	add("center", 0, values["source"])
+	space("left", 20)
+	add("right", 0, values["draft date"])
+	add("right", 0, values["notes"])
+	add("left", 0, values["copyright"])
+	space("left", 1)
+	for _, key := range keys {
+		switch key {
+		case "title", "credit", "author", "source", "draft date", "notes", "copyright":
+		default:
+			add("left", 0, values[key])
+		}
+	}
+	return paragraphs
+}
+
+// exportRuns returns an element's content as styled runs with the
+// centering markers and dual dialogue caret removed.
+func exportRuns(element *Element) []*layoutRun {
+	prefix, suffix := elementMarkers(element)
+	return toRuns(trimMarkers(styledChars(element), prefix, suffix))
+}
+
+// exportStyle returns the paragraph style name and alignment used by
+// screenwriting applications for an element, an empty style means the
+// element is not exported (e.g. empty lines, sections and synopses).
+func exportStyle(element *Element) (string, string) {
+	content := strings.TrimSpace(element.Content)
+	switch element.Type {
+	case SceneHeadingType:
+		if isEndOfScript(element) {
+			return "Action", "center"
+		}
+		if !isScene(element) {
+			return "Transition", ""
+		}
+		return "Scene Heading", ""
+	case ActionType:
+		return "Action", ""
+	case CharacterType:
+		return "Character", ""
+	case DialogueType:
+		return "Dialogue", ""
+	case ParentheticalType:
+		return "Parenthetical", ""
+	case TransitionType:
+		if strings.HasPrefix(content, ">") && strings.HasSuffix(content, "<") {
+			return "Action", "center"
+		}
+		return "Transition", ""
+	case LyricType:
+		return "Lyrics", ""
+	case CenterAlignment:
+		return "Action", "center"
+	case GeneralTextType:
+		return "General", ""
+	}
+	return "", ""
+}
@@ -0,0 +1,663 @@
+// Package fountain is a Golang package supporting Fountain screenplay markup.
+//
+// layout.go lays out a screenplay into pages using the standard screenplay
+// format (Courier 12pt, ten characters per inch, six lines per inch).
+package fountain
+
+import (
+	"strings"
+	"unicode"
+)
+
+// PaperSize describes the page dimensions and margins in inches
+type PaperSize struct {
+	Name   string
+	Width  float64
+	Height float64
+	Top    float64
+	Bottom float64
+	Left   float64
+	Right  float64
+}
+
+var (
+	// LetterPaper is US Letter with standard screenplay margins
+	LetterPaper = &PaperSize{Name: "letter", Width: 8.5, Height: 11, Top: 1, Bottom: 1, Left: 1.5, Right: 1}
+	// A4Paper is A4 with standard screenplay margins
+	A4Paper = &PaperSize{Name: "a4", Width: 8.27, Height: 11.69, Top: 1, Bottom: 1, Left: 1.5, Right: 1}
+)
+
+const (
+	// charsPerInch and linesPerInch for Courier 12pt
+	charsPerInch = 10
+	linesPerInch = 6
+)
+
+// run style bits used in laying out styled text
+const (
+	runBold = 1 << iota
+	runItalic
+	runUnderline
+)
+
+// getPaperSize returns the paper size by name, US Letter is the default.
+func getPaperSize(name string) *PaperSize {
+	if strings.ToLower(name) == "a4" {
+		return A4Paper
+	}
+	return LetterPaper
+}
+
+// linesPerPage returns the number of body lines between the margins
+func (paper *PaperSize) linesPerPage() int {
+	return int((paper.Height - paper.Top - paper.Bottom) * linesPerInch)
+}
+
+// rightEdge returns the position of the right margin in inches
+func (paper *PaperSize) rightEdge() float64 {
+	return paper.Width - paper.Right
+}
+
+// styledChar is a character and the styles applied to it
+type styledChar struct {
+	r     rune
+	style int
+}
+
+// layoutRun is a run of text sharing a style
+type layoutRun struct {
+	text  string
+	style int
+}
+
+// layoutChunk is text placed at a horizontal position (inches from the
+// left edge of the page).
+type layoutChunk struct {
+	x    float64
+	runs []*layoutRun
+}
+
+// layoutLine is one line of a page, a nil line is a blank line.
+type layoutLine struct {
+	element *Element
+	chunks  []*layoutChunk
+}
+
+// layoutPage holds the lines for a page of the script
+type layoutPage struct {
+	number int
+	lines  []*layoutLine
+}
+
+// flattenSpans turns spans into a list of styled characters
+func flattenSpans(spans []*Span, style int, out []styledChar) []styledChar {
+	for _, span := range spans {
+		s := style
+		switch span.Style {
+		case BoldStyle:
+			s |= runBold
+		case ItalicStyle:
+			s |= runItalic
+		case UnderlineStyle:
+			s |= runUnderline
+		}
+		if span.Spans != nil {
+			out = flattenSpans(span.Spans, s, out)
+		} else {
+			for _, r := range span.Text {
+				out = append(out, styledChar{r: r, style: s})
+			}
+		}
+	}
+	return out
+}
+
+// styledChars returns an element's content as styled characters
+func styledChars(element *Element) []styledChar {
+	if element.Spans != nil {
+		return flattenSpans(element.Spans, 0, nil)
+	}
+	out := []styledChar{}
+	for _, r := range element.Content {
+		out = append(out, styledChar{r: r})
+	}
+	return out
+}
+
+// splitStyledLines splits styled characters at line breaks, tabs are
+// expanded and surrounding spaces trimmed.
+func splitStyledLines(chars []styledChar) [][]styledChar {
+	lines := [][]styledChar{}
+	line := []styledChar{}
+	for _, c := range chars {
+		switch c.r {
+		case '\n':
+			lines = append(lines, trimStyled(line))
+			line = []styledChar{}
+		case '\r':
+		case '\t':
+			line = append(line, styledChar{r: ' ', style: c.style})
+		default:
+			line = append(line, c)
+		}
+	}
+	return append(lines, trimStyled(line))
+}
+
+// trimStyled removes leading and trailing spaces
+func trimStyled(chars []styledChar) []styledChar {
+	for len(chars) > 0 && unicode.IsSpace(chars[0].r) {
+		chars = chars[1:]
+	}
+	for len(chars) > 0 && unicode.IsSpace(chars[len(chars)-1].r) {
+		chars = chars[0 : len(chars)-1]
+	}
+	return chars
+}
+
+// trimMarkers removes markers (e.g. "<" and ">" around centered text)
+// from the start and end of the text.
+func trimMarkers(chars []styledChar, prefix string, suffix string) []styledChar {
+	chars = trimStyled(chars)
+	if prefix != "" && len(chars) > 0 && strings.ContainsRune(prefix, chars[0].r) {
+		chars = chars[1:]
+	}
+	if suffix != "" && len(chars) > 0 && strings.ContainsRune(suffix, chars[len(chars)-1].r) {
+		chars = chars[0 : len(chars)-1]
+	}
+	return trimStyled(chars)
+}
+
+// elementMarkers returns the markers kept in the content of an element
+// that are not printed, the forcing markers (e.g. "!" for action) are
+// removed by the parser.
+func elementMarkers(element *Element) (string, string) {
+	switch element.Type {
+	case CharacterType:
+		return "", "^"
+	case CenterAlignment:
+		return ">", "<"
+	case TransitionType:
+		if strings.HasSuffix(strings.TrimSpace(element.Content), "<") {
+			return ">", "<"
+		}
+	}
+	return "", ""
+}
+
+// upperStyled upper cases the text
+func upperStyled(chars []styledChar) []styledChar {
+	out := make([]styledChar, len(chars))
+	for i, c := range chars {
+		out[i] = styledChar{r: unicode.ToUpper(c.r), style: c.style}
+	}
+	return out
+}
+
+// wrapStyled word wraps the styled text to width characters
+func wrapStyled(chars []styledChar, width int) [][]styledChar {
+	lines := [][]styledChar{}
+	if len(chars) <= width || width < 1 {
+		return append(lines, chars)
+	}
+	line := []styledChar{}
+	word := []styledChar{}
+	flushWord := func() {
+		for len(word) > 0 {
+			if len(line) > 0 && len(line)+1+len(word) > width {
+				lines = append(lines, line)
+				line = []styledChar{}
+			}
+			if len(line) > 0 {
+				line = append(line, styledChar{r: ' ', style: word[0].style})
+			}
+			if len(word) > width {
+				// Hard break a word that is longer than the line
+				line = append(line, word[0:width]...)
+				word = word[width:]
+				lines = append(lines, line)
+				line = []styledChar{}
+				continue
+			}
+			line = append(line, word...)
+			word = []styledChar{}
+		}
+	}
+	for _, c := range chars {
+		if c.r == ' ' {
+			flushWord()
+			continue
+		}
+		word = append(word, c)
+	}
+	flushWord()
+	if len(line) > 0 {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// toRuns groups styled characters into runs
+func toRuns(chars []styledChar) []*layoutRun {
+	runs := []*layoutRun{}
+	buf := []rune{}
+	style := 0
+	for i, c := range chars {
+		if i > 0 && c.style != style {
+			runs = append(runs, &layoutRun{text: string(buf), style: style})
+			buf = []rune{}
+		}
+		style = c.style
+		buf = append(buf, c.r)
+	}
+	if len(buf) > 0 {
+		runs = append(runs, &layoutRun{text: string(buf), style: style})
+	}
+	return runs
+}
+
+// textLine creates a line holding a single chunk of plain text
+func textLine(element *Element, x float64, text string) *layoutLine {
+	return &layoutLine{element: element, chunks: []*layoutChunk{{x: x, runs: []*layoutRun{{text: text}}}}}
+}
+
+// layoutBlock is a group of lines that are placed on a page together
+type layoutBlock struct {
+	element *Element
+	// space is the number of blank lines before the block
+	space int
+	lines []*layoutLine
+	// keepWithNext keeps the block on the same page as the next block
+	// (e.g. a scene heading)
+	keepWithNext bool
+	// splittable blocks can be split across pages (e.g. action)
+	splittable bool
+	// speech holds the character name when the block is a speech
+	speech     string
+	characterX float64
+	pageBreak  bool
+}
+
+// elementIndent describes where an element type is placed on the page
+type elementIndent struct {
+	x     float64
+	width int
+	space int
+}
+
+// indentFor returns the position, width and spacing for an element type
+func indentFor(paper *PaperSize, elemType int) elementIndent {
+	actionWidth := int((paper.rightEdge() - paper.Left) * charsPerInch)
+	switch elemType {
+	case SceneHeadingType:
+		return elementIndent{x: paper.Left, width: actionWidth, space: 2}
+	case CharacterType:
+		return elementIndent{x: 3.7, width: 33, space: 1}
+	case ParentheticalType:
+		return elementIndent{x: 3.1, width: 24}
+	case DialogueType:
+		return elementIndent{x: 2.5, width: 35}
+	case TransitionType:
+		return elementIndent{x: paper.rightEdge() - 2, width: 20, space: 1}
+	default:
+		return elementIndent{x: paper.Left, width: actionWidth, space: 1}
+	}
+}
+
+// elementLines lays out the lines of a single element at the given indent
+func elementLines(paper *PaperSize, element *Element, indent elementIndent) []*layoutLine {
+	chars := styledChars(element)
+	right := false
+	centered := false
+	prefix, suffix := elementMarkers(element)
+	if prefix != "" || suffix != "" {
+		chars = trimMarkers(chars, prefix, suffix)
+	}
+	switch element.Type {
+	case SceneHeadingType, CharacterType:
+		chars = upperStyled(chars)
+	case CenterAlignment:
+		centered = true
+	case TransitionType:
+		chars = upperStyled(chars)
+		right = !strings.HasSuffix(strings.TrimSpace(element.Content), "IN:")
+	}
+	lines := []*layoutLine{}
+	for _, src := range splitStyledLines(chars) {
+		for _, line := range wrapStyled(src, indent.width) {
+			x := indent.x
+			if right {
+				x = paper.rightEdge() - float64(len(line))/charsPerInch
+			}
+			if centered {
+				x = paper.Left + ((paper.rightEdge()-paper.Left)-float64(len(line))/charsPerInch)/2
+			}
+			lines = append(lines, &layoutLine{element: element, chunks: []*layoutChunk{{x: x, runs: toRuns(line)}}})
+		}
+	}
+	if element.Type == SceneHeadingType && element.SceneNumber != "" && len(lines) > 0 {
+		// Scene numbers are printed in both margins
+		first := lines[0]
+		first.chunks = append(first.chunks,
+			&layoutChunk{x: paper.Left - float64(len(element.SceneNumber)+3)/charsPerInch, runs: []*layoutRun{{text: element.SceneNumber}}},
+			&layoutChunk{x: paper.rightEdge() + 0.2, runs: []*layoutRun{{text: element.SceneNumber}}})
+	}
+	return lines
+}
+
+// speechLines lays out a character with their parentheticals and dialogue,
+// offset shifts the speech left or right (e.g. for dual dialogue) and
+// narrow reduces the width.
+func speechLines(paper *PaperSize, elements []*Element, offset float64, narrow int) []*layoutLine {
+	lines := []*layoutLine{}
+	for _, element := range elements {
+		indent := indentFor(paper, element.Type)
+		indent.x += offset
+		indent.width -= narrow
+		lines = append(lines, elementLines(paper, element, indent)...)
+	}
+	return lines
+}
+
+// layoutBlocks turns the script elements into blocks of lines
+func layoutBlocks(paper *PaperSize, elements []*Element) []*layoutBlock {
+	blocks := []*layoutBlock{}
+	for i := 0; i < len(elements); i++ {
+		element := elements[i]
+		switch element.Type {
+		case EmptyType, NoteType, BoneyardType, SectionType, SynopsisType:
+			// Not printed, spacing is handled by the element types
+		case PageFeed:
+			blocks = append(blocks, &layoutBlock{element: element, pageBreak: true})
+		case CharacterType:
+			j := i + 1
+			for j < len(elements) && isSpeech(elements[j]) {
+				j++
+			}
+			indent := indentFor(paper, CharacterType)
+			blocks = append(blocks, &layoutBlock{
+				element:    element,
+				space:      indent.space,
+				lines:      speechLines(paper, elements[i:j], 0, 0),
+				splittable: true,
+				speech:     strings.ToUpper(strings.TrimSpace(element.Content)),
+				characterX: indent.x,
+			})
+			i = j - 1
+		case DualDialogueType:
+			left, right := element.DualDialogueBlocks()
+			leftLines := speechLines(paper, left, -1, 10)
+			rightLines := speechLines(paper, right, 1.6, 10)
+			lines := []*layoutLine{}
+			for k := 0; k < len(leftLines) || k < len(rightLines); k++ {
+				line := &layoutLine{element: element}
+				if k < len(leftLines) {
+					line.chunks = append(line.chunks, leftLines[k].chunks...)
+				}
+				if k < len(rightLines) {
+					line.chunks = append(line.chunks, rightLines[k].chunks...)
+				}
+				lines = append(lines, line)
+			}
+			blocks = append(blocks, &layoutBlock{element: element, space: 1, lines: lines})
+		default:
+			indent := indentFor(paper, element.Type)
+			blocks = append(blocks, &layoutBlock{
+				element:      element,
+				space:        indent.space,
+				lines:        elementLines(paper, element, indent),
+				keepWithNext: element.Type == SceneHeadingType,
+				splittable:   element.Type == ActionType || element.Type == GeneralTextType,
+			})
+		}
+	}
+	return blocks
+}
+
+// pageLayout tracks the pages as blocks are placed
+type pageLayout struct {
+	paper        *PaperSize
+	linesPerPage int
+	pages        []*layoutPage
+	current      *layoutPage
+}
+
+func (l *pageLayout) newPage() {
+	l.current = &layoutPage{number: len(l.pages) + 1}
+	l.pages = append(l.pages, l.current)
+}
+
+func (l *pageLayout) remaining() int {
+	return l.linesPerPage - len(l.current.lines)
+}
+
+// spaceFor returns the blank lines needed before a block, none at the
+// top of the page.
+func (l *pageLayout) spaceFor(block *layoutBlock) int {
+	if len(l.current.lines) == 0 {
+		return 0
+	}
+	return block.space
+}
+
+func (l *pageLayout) addLines(space int, lines []*layoutLine) {
+	for i := 0; i < space; i++ {
+		l.current.lines = append(l.current.lines, nil)
+	}
+	l.current.lines = append(l.current.lines, lines...)
+}
+
+// minLines is the number of lines of a block that must fit on the
+// page when it is kept with the previous block.
+func minLines(block *layoutBlock) int {
+	if block.speech != "" {
+		// character plus the first line of dialogue
+		return 2
+	}
+	if len(block.lines) < 2 {
+		return len(block.lines)
+	}
+	return 2
+}
+
+// place puts a block on the current page, splitting it or moving
+// it to the next page as needed.
+func (l *pageLayout) place(block *layoutBlock, next *layoutBlock) {
+	space := l.spaceFor(block)
+	need := space + len(block.lines)
+	if block.keepWithNext && next != nil && !next.pageBreak {
+		need += next.space + minLines(next)
+	}
+	if need <= l.remaining() {
+		l.addLines(space, block.lines)
+		return
+	}
+	avail := l.remaining() - space
+	switch {
+	case block.speech != "" && l.splitSpeech(block, avail, space):
+		return
+	case block.splittable && block.speech == "" && avail >= 2 && len(block.lines)-avail >= 2:
+		l.addLines(space, block.lines[0:avail])
+		l.newPage()
+		l.place(&layoutBlock{element: block.element, lines: block.lines[avail:], splittable: true}, next)
+		return
+	}
+	if len(l.current.lines) == 0 {
+		// The block is longer than a page (or can't be kept with the
+		// next one on a page), break it where we must
+		n := min(len(block.lines), l.linesPerPage)
+		l.addLines(0, block.lines[0:n])
+		if n == len(block.lines) {
+			return
+		}
+		l.newPage()
+		rest := *block
+		rest.lines = block.lines[n:]
+		l.place(&rest, next)
+		return
+	}
+	l.newPage()
+	l.place(block, next)
+}
+
+// splitSpeech splits dialogue across pages adding (MORE) and (CONT'D).
+// It returns false if the speech can't be split on this page.
+func (l *pageLayout) splitSpeech(block *layoutBlock, avail int, space int) bool {
+	// Find the character lines (the cue may wrap)
+	cue := 0
+	for cue < len(block.lines) && block.lines[cue].element.Type == CharacterType {
+		cue++
+	}
+	// Leave room for (MORE) and keep at least two lines of dialogue
+	p := avail - cue - 1
+	if p > len(block.lines)-cue-1 {
+		p = len(block.lines) - cue - 1
+	}
+	// Don't end the page on a parenthetical
+	for p > 0 && block.lines[cue+p-1].element.Type == ParentheticalType {
+		p--
+	}
+	if p < 2 {
+		return false
+	}
+	// Prefer breaking at the end of a sentence
+	for i := p; i >= 2 && i > p-3; i-- {
+		if endsSentence(block.lines[cue+i-1]) {
+			p = i
+			break
+		}
+	}
+	more := textLine(block.element, block.characterX, "(MORE)")
+	lines := append([]*layoutLine{}, block.lines[0:cue+p]...)
+	l.addLines(space, append(lines, more))
+	l.newPage()
+	name := block.speech
+	if !strings.Contains(name, "(CONT'D)") {
+		name += " (CONT'D)"
+	}
+	rest := &layoutBlock{
+		element:    block.element,
+		lines:      append([]*layoutLine{textLine(block.element, block.characterX, name)}, block.lines[cue+p:]...),
+		splittable: true,
+		speech:     block.speech,
+		characterX: block.characterX,
+	}
+	l.place(rest, nil)
+	return true
+}
+
+// endsSentence returns true if the line ends with sentence punctuation
+func endsSentence(line *layoutLine) bool {
+	if line == nil || len(line.chunks) == 0 {
+		return false
+	}
+	runs := line.chunks[len(line.chunks)-1].runs
+	if len(runs) == 0 {
+		return false
+	}
+	text := strings.TrimSpace(runs[len(runs)-1].text)
+	return strings.HasSuffix(text, ".") || strings.HasSuffix(text, "?") || strings.HasSuffix(text, "!")
+}
+
+// layoutScript lays out the script elements into pages
+func layoutScript(paper *PaperSize, elements []*Element) []*layoutPage {
+	l := &pageLayout{paper: paper, linesPerPage: paper.linesPerPage()}
+	l.newPage()
+	blocks := layoutBlocks(paper, elements)
+	for i, block := range blocks {
+		if block.pageBreak {
+			if len(l.current.lines) > 0 {
+				l.newPage()
+			}
+			continue
+		}
+		var next *layoutBlock
+		if i+1 < len(blocks) {
+			next = blocks[i+1]
+		}
+		l.place(block, next)
+	}
+	// Drop a trailing empty page
+	if len(l.pages) > 1 && len(l.current.lines) == 0 {
+		l.pages = l.pages[0 : len(l.pages)-1]
+	}
+	return l.pages
+}
+
+// titlePageValue returns the cleaned up lines of a title page element
+func titlePageValue(element *Element) [][]styledChar {
+	lines := [][]styledChar{}
+	for _, line := range splitStyledLines(styledChars(element)) {
+		if len(line) > 0 {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// layoutTitlePage places the title page elements in their standard
+// positions, title, credit, author and source centered on the page,
+// contact information bottom left and draft date bottom right.
+func layoutTitlePage(paper *PaperSize, titlePage []*Element) *layoutPage {
+	if len(titlePage) == 0 {
+		return nil
+	}
+	values := map[string][][]styledChar{}
+	for _, element := range titlePage {
+		key := titlePageKey(element.Name)
+		values[key] = append(values[key], titlePageValue(element)...)
+	}
+	linesPerPage := paper.linesPerPage()
+	page := &layoutPage{lines: make([]*layoutLine, linesPerPage)}
+	width := int((paper.rightEdge() - paper.Left) * charsPerInch)
+	center := func(row int, chars []styledChar, upper bool) int {
+		if upper {
+			chars = upperStyled(chars)
+		}
+		for _, line := range wrapStyled(chars, width) {
+			if row >= linesPerPage {
+				return row
+			}
+			x := paper.Left + ((paper.rightEdge()-paper.Left)-float64(len(line))/charsPerInch)/2
+			page.lines[row] = &layoutLine{chunks: []*layoutChunk{{x: x, runs: toRuns(line)}}}
+			row++
+		}
+		return row
+	}
+	row := linesPerPage / 3
+	for _, key := range []string{"title", "credit", "author", "source"} {
+		if lines, ok := values[key]; ok {
+			for _, line := range lines {
+				row = center(row, line, key == "title")
+			}
+			row++
+		}
+	}
+	// Bottom left and right blocks
+	bottom := func(keys []string, alignRight bool) {
+		lines := [][]styledChar{}
+		for _, key := range keys {
+			lines = append(lines, values[key]...)
+		}
+		row := linesPerPage - len(lines)
+		for _, line := range lines {
+			if row < 0 || row >= linesPerPage {
+				row++
+				continue
+			}
+			x := paper.Left
+			if alignRight {
+				x = paper.rightEdge() - float64(len(line))/charsPerInch
+			}
+			chunk := &layoutChunk{x: x, runs: toRuns(line)}
+			if page.lines[row] == nil {
+				page.lines[row] = &layoutLine{}
+			}
+			page.lines[row].chunks = append(page.lines[row].chunks, chunk)
+			row++
+		}
+	}
+	bottom([]string{"contact", "copyright"}, false)
+	bottom([]string{"draft date", "notes"}, true)
+	return page
+}
@@ -0,0 +1,260 @@
+// Package fountain is a Golang package supporting Fountain screenplay markup.
+//
+// lint.go checks a screenplay for markup that is likely to be misread
+// (e.g. unterminated notes, character cues without dialogue).
+package fountain
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	// SeverityError is used for markup that hides or breaks the script,
+	// e.g. an unterminated note or boneyard
+	SeverityError = "error"
+	// SeverityWarning is used for markup that is likely misread
+	SeverityWarning = "warning"
+)
+
+// reInlineNotes matches notes and boneyard found inside a line,
+// including those started or ended on another line
+var reInlineNotes = regexp.MustCompile(`\[\[.*?(\]\]|$)|/\*.*?(\*/|$)|^.*?(\]\]|\*/)`)
+
+// Diagnostic is a problem found in a screenplay by Lint. Line and
+// Column start at one, Code names the check (e.g. "unterminated-note").
+type Diagnostic struct {
+	Line     int    `json:"line" yaml:"line"`
+	Column   int    `json:"column" yaml:"column"`
+	Severity string `json:"severity" yaml:"severity"`
+	Code     string `json:"code" yaml:"code"`
+	Message  string `json:"message" yaml:"message"`
+}
+
+// String returns the diagnostic as "line:column: severity: message"
+func (d *Diagnostic) String() string {
+	return fmt.Sprintf("%d:%d: %s: %s", d.Line, d.Column, d.Severity, d.Message)
+}
+
+// linter collects the diagnostics while walking the elements
+type linter struct {
+	diagnostics []*Diagnostic
+}
+
+// lineOf returns the source line of the i-th line of an element's content
+func lineOf(element *Element, i int) int {
+	if element == nil || element.Start == nil {
+		return 0
+	}
+	return element.Start.Line + i
+}
+
+func (l *linter) add(line int, column int, severity string, code string, format string, args ...interface{}) {
+	l.diagnostics = append(l.diagnostics, &Diagnostic{
+		Line:     line,
+		Column:   column,
+		Severity: severity,
+		Code:     code,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// flatElements returns the elements with dual dialogue replaced by the
+// speeches it holds
+func flatElements(elements []*Element) []*Element {
+	flat := []*Element{}
+	for _, element := range elements {
+		if element.Type == DualDialogueType {
+			flat = append(flat, element.Elements...)
+		} else {
+			flat = append(flat, element)
+		}
+	}
+	return flat
+}
+
+// checkUnterminated looks for notes ([[ ... ]]) and boneyard (/* ... */)
+// that are opened but never closed.
+func (l *linter) checkUnterminated(elements []*Element) {
+	var note, boneyard *Diagnostic
+	for _, element := range elements {
+		for i, line := range strings.Split(element.Content, "\n") {
+			for j := 0; j+1 < len(line); j++ {
+				token := line[j : j+2]
+				switch {
+				case boneyard != nil:
+					if token == "*/" {
+						boneyard = nil
+						j++
+					}
+				case token == "/*":
+					boneyard = &Diagnostic{Line: lineOf(element, i), Column: j + 1}
+					j++
+				case note != nil:
+					if token == "]]" {
+						note = nil
+						j++
+					}
+				case token == "[[":
+					note = &Diagnostic{Line: lineOf(element, i), Column: j + 1}
+					j++
+				}
+			}
+		}
+	}
+	if note != nil {
+		l.add(note.Line, note.Column, SeverityError, "unterminated-note", "note opened with [[ is never closed with ]]")
+	}
+	if boneyard != nil {
+		l.add(boneyard.Line, boneyard.Column, SeverityError, "unterminated-boneyard", "boneyard opened with /* is never closed with */")
+	}
+}
+
+// hasUnbalancedEmphasis returns true if a line has an emphasis marker
+// (*, ** or _) without its partner.
+func hasUnbalancedEmphasis(line string) bool {
+	line = reInlineNotes.ReplaceAllString(line, "")
+	line = strings.NewReplacer(`\*`, "", `\_`, "").Replace(line)
+	if !strings.ContainsAny(line, "*_") {
+		return false
+	}
+	return strings.ContainsAny(spansText(ParseEmphasis(line)), "*_")
+}
+
+// checkElements looks for misread elements, character cues without
+// dialogue, scene headings without a time of day and unbalanced emphasis.
+func (l *linter) checkElements(elements []*Element) {
+	ended := false
+	for i, element := range elements {
+		var prev, next *Element
+		if i > 0 {
+			prev = elements[i-1]
+		}
+		if i+1 < len(elements) {
+			next = elements[i+1]
+		}
+		lines := strings.Split(element.Content, "\n")
+		content := strings.TrimSpace(element.Content)
+		switch element.Type {
+		case SceneHeadingType:
+			if isEndOfScript(element) {
+				ended = true
+			}
+			if scene := ParseSceneHeading(element); scene != nil && scene.Setting != "" && scene.TimeOfDay == "" {
+				l.add(lineOf(element, 0), 1, SeverityWarning, "missing-time-of-day", "scene heading %q has no time of day (e.g. DAY, NIGHT)", content)
+			}
+		case CharacterType:
+			if CharacterName(element) == "" {
+				l.add(lineOf(element, 0), 1, SeverityWarning, "empty-cue", "character cue has no name")
+			} else if next == nil || !isSpeech(next) {
+				l.add(lineOf(element, 0), 1, SeverityWarning, "cue-without-dialogue", "character cue %q has no dialogue", content)
+			}
+		case DialogueType:
+			for j, line := range lines {
+				if s := strings.TrimSpace(line); strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")") {
+					l.add(lineOf(element, j), 1, SeverityWarning, "parenthetical-in-dialogue", "parenthetical %q is read as dialogue", s)
+				}
+			}
+		case ActionType:
+			if prev != nil && isSpeech(prev) {
+				l.add(lineOf(element, 0), 1, SeverityWarning, "ambiguous-action", "%q follows dialogue without a blank line and is read as action", content)
+			} else if len(lines) == 1 && !element.Forced && content == strings.ToUpper(content) && strings.HasSuffix(content, "TO:") {
+				l.add(lineOf(element, 0), 1, SeverityWarning, "ambiguous-transition", "%q looks like a transition but is read as action", content)
+			}
+		case GeneralTextType:
+			if ended || content == "" {
+				break
+			}
+			if len(lines) == 1 && isCharacter(content, EmptyType) {
+				l.add(lineOf(element, 0), 1, SeverityWarning, "cue-without-dialogue", "character cue %q has no dialogue", content)
+			} else {
+				l.add(lineOf(element, 0), 1, SeverityWarning, "ambiguous-line", "%q could not be classified", content)
+			}
+		}
+		switch element.Type {
+		case NoteType, BoneyardType, EmptyType, PageFeed:
+		default:
+			for j, line := range lines {
+				if hasUnbalancedEmphasis(line) {
+					l.add(lineOf(element, j), 1, SeverityWarning, "unbalanced-emphasis", "emphasis marker without a partner in %q", strings.TrimSpace(line))
+				}
+			}
+		}
+	}
+}
+
+// editDistance returns the Levenshtein distance between a and b
+func editDistance(a string, b string) int {
+	s, t := []rune(a), []rune(b)
+	row := make([]int, len(t)+1)
+	for j := range row {
+		row[j] = j
+	}
+	for i := 1; i <= len(s); i++ {
+		prev := row[0]
+		row[0] = i
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			current := row[j]
+			row[j] = min(row[j]+1, row[j-1]+1, prev+cost)
+			prev = current
+		}
+	}
+	return row[len(t)]
+}
+
+// normalizeName reduces a character name to its letters and digits
+func normalizeName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9') || r > 127 {
+			return r
+		}
+		return -1
+	}, strings.ToUpper(name))
+}
+
+// checkSpellings looks for character names that differ by punctuation,
+// spacing or a single letter (e.g. "JEAN-LOUIS" and "JEAN LOUIS"). The
+// name used least is reported where it first appears.
+func (l *linter) checkSpellings(doc *Fountain) {
+	stats := doc.CharacterStats()
+	for i, a := range stats {
+		for _, b := range stats[i+1:] {
+			na, nb := normalizeName(a.Name), normalizeName(b.Name)
+			if na != nb && (len(na) < 5 || len(nb) < 5 || editDistance(na, nb) != 1) {
+				continue
+			}
+			rare, common := b, a
+			if a.DialogueBlocks < b.DialogueBlocks {
+				rare, common = a, b
+			}
+			l.add(rare.FirstLine, 1, SeverityWarning, "character-spelling", "character %q may be a misspelling of %q", rare.Name, common.Name)
+		}
+	}
+}
+
+// Lint checks a screenplay for markup that is likely to be misread and
+// returns the diagnostics ordered by line. It flags ambiguous lines
+// (e.g. action directly after dialogue), unterminated notes and boneyard,
+// character cues without dialogue, scene headings without a time of day,
+// inconsistent spellings of character names and unbalanced emphasis.
+func Lint(doc *Fountain) []*Diagnostic {
+	l := new(linter)
+	l.diagnostics = []*Diagnostic{}
+	elements := flatElements(doc.Elements)
+	l.checkUnterminated(elements)
+	l.checkElements(elements)
+	l.checkSpellings(doc)
+	sort.SliceStable(l.diagnostics, func(i, j int) bool {
+		if l.diagnostics[i].Line == l.diagnostics[j].Line {
+			return l.diagnostics[i].Column < l.diagnostics[j].Column
+		}
+		return l.diagnostics[i].Line < l.diagnostics[j].Line
+	})
+	return l.diagnostics
+}
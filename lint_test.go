@@ -0,0 +1,86 @@
+package fountain
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	src := []byte(`Title: Lint
+
+INT. KITCHEN - DAY
+
+BOB
+The pot is **hot.
+It burns.
+
+ALICE
+   (quietly)
+So it is.
+
+ALLICE
+Ouch!
+
+EXT. GARDEN
+
+Crickets. [[A note that is
+never closed.
+
+CUT TO:
+
+INT. HALL - NIGHT
+
+BOB
+
+/* Cut the ending
+THE END.
+`)
+	doc, err := Parse(src)
+	assertOK(t, err, "Parse(src)")
+	expected := []string{
+		"6:1: warning: unbalanced-emphasis",
+		"7:1: warning: ambiguous-action",
+		"13:1: warning: character-spelling",
+		"16:1: warning: missing-time-of-day",
+		"18:11: error: unterminated-note",
+		"21:1: warning: ambiguous-transition",
+		"25:1: warning: cue-without-dialogue",
+		"27:1: error: unterminated-boneyard",
+	}
+	diagnostics := Lint(doc)
+	got := []string{}
+	for _, d := range diagnostics {
+		got = append(got, strings.SplitN(d.String(), ": ", 3)[0]+": "+d.Severity+": "+d.Code)
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected\n%s\ngot\n%s", strings.Join(expected, "\n"), strings.Join(got, "\n"))
+	}
+	for _, d := range diagnostics {
+		if d.Code == "character-spelling" && d.Message != `character "ALLICE" may be a misspelling of "ALICE"` {
+			t.Errorf("unexpected message %q", d.Message)
+		}
+	}
+
+	// An indented parenthetical is read as one, a parenthetical left in
+	// the dialogue (e.g. by an import) is flagged
+	doc = &Fountain{Elements: []*Element{
+		{Type: CharacterType, Content: "ALICE"},
+		{Type: DialogueType, Content: "So it is.\n(quietly)\nOuch!"},
+	}}
+	if diagnostics := Lint(doc); len(diagnostics) != 1 || diagnostics[0].Code != "parenthetical-in-dialogue" {
+		t.Errorf("expected parenthetical-in-dialogue, got %+v", diagnostics)
+	}
+
+	// Action forced with "!" is not ambiguous
+	doc, err = Parse([]byte("Bob runs.\n\n!CUT TO:\n"))
+	assertOK(t, err, "Parse(src)")
+	if diagnostics := Lint(doc); len(diagnostics) != 0 {
+		t.Errorf("expected no diagnostics for forced action, got %s", diagnostics[0])
+	}
+
+	doc, err = ParseFile("testdata/sample-01.fountain")
+	assertOK(t, err, "ParseFile(testdata/sample-01.fountain)")
+	if diagnostics := Lint(doc); len(diagnostics) != 0 {
+		t.Errorf("expected no diagnostics for sample-01, got %s", diagnostics[0])
+	}
+}
@@ -0,0 +1,276 @@
+// Package fountain is a Golang package supporting Fountain screenplay markup.
+//
+// osf.go reads and writes Open Screenplay Format (OSF) XML documents.
+package fountain
+
+import (
+	"bytes"
+	"encoding/xml"
+	"os"
+	"strings"
+)
+
+// osfDocument is the root element of an Open Screenplay Format document
+type osfDocument struct {
+	XMLName    xml.Name       `xml:"document"`
+	Type       string         `xml:"type,attr"`
+	Version    string         `xml:"version,attr"`
+	Styles     *osfStyles     `xml:"styles,omitempty"`
+	Paragraphs *osfParagraphs `xml:"paragraphs"`
+	TitlePage  *osfParagraphs `xml:"titlepage,omitempty"`
+}
+
+// osfStyles holds the style definitions as is
+type osfStyles struct {
+	Definitions string `xml:",innerxml"`
+}
+
+// osfParagraphs holds a list of paragraphs
+type osfParagraphs struct {
+	Paragraphs []*osfParagraph `xml:"para"`
+}
+
+// osfParagraph is a single script element. Scene numbers are held in
+// the number attribute.
+type osfParagraph struct {
+	Number string     `xml:"number,attr,omitempty"`
+	Style  *osfStyle  `xml:"style"`
+	Text   []*osfText `xml:"text"`
+}
+
+// osfStyle names the paragraph style and any overrides
+type osfStyle struct {
+	Name            string `xml:"name,attr,omitempty"`
+	BaseStyleName   string `xml:"basestylename,attr,omitempty"`
+	Align           string `xml:"align,attr,omitempty"`
+	PageBreakBefore string `xml:"pagebreakbefore,attr,omitempty"`
+}
+
+// osfText is a run of text, styles are set with "1"
+type osfText struct {
+	Bold      string `xml:"bold,attr,omitempty"`
+	Italic    string `xml:"italic,attr,omitempty"`
+	Underline string `xml:"underline,attr,omitempty"`
+	Value     string `xml:",chardata"`
+}
+
+// osfTypes maps OSF paragraph style names to element types
+var osfTypes = map[string]int{
+	"Scene Heading": SceneHeadingType,
+	"Action":        ActionType,
+	"Character":     CharacterType,
+	"Dialogue":      DialogueType,
+	"Parenthetical": ParentheticalType,
+	"Transition":    TransitionType,
+	"Shot":          SceneHeadingType,
+	"Lyrics":        LyricType,
+	"Normal Text":   GeneralTextType,
+}
+
+// osfStyleDefinitions are the built in paragraph styles written to
+// new documents
+const osfStyleDefinitions = `
+    <style name="Normal Text" builtin="1" builtin_index="0" label="Normal Text" font="Courier" size="12"/>
+    <style name="Scene Heading" builtin="1" builtin_index="1" label="Scene Heading" basestylename="Normal Text" style_enter="Action" style_tab_after="Action" font="Courier" size="12" spacebefore="2.0" keepwithnext="1" allcaps="1"/>
+    <style name="Action" builtin="1" builtin_index="2" label="Action" basestylename="Normal Text" style_tab_before="Character" font="Courier" size="12" spacebefore="1.0"/>
+    <style name="Character" builtin="1" builtin_index="3" label="Character" basestylename="Normal Text" style_enter="Dialogue" style_tab_before="Action" style_tab_after="Parenthetical" font="Courier" size="12" spacebefore="1.0" keepwithnext="1" leftindent="635" allcaps="1"/>
+    <style name="Parenthetical" builtin="1" builtin_index="4" label="Parenthetical" basestylename="Normal Text" style_enter="Dialogue" style_tab_before="Dialogue" style_tab_after="Dialogue" font="Courier" size="12" keepwithnext="1" leftindent="508" rightindent="508"/>
+    <style name="Dialogue" builtin="1" builtin_index="5" label="Dialogue" basestylename="Normal Text" style_enter="Action" style_tab_before="Parenthetical" style_tab_after="Parenthetical" font="Courier" size="12" leftindent="330" rightindent="254"/>
+    <style name="Transition" builtin="1" builtin_index="6" label="Transition" basestylename="Normal Text" style_enter="Scene Heading" style_tab_after="Action" font="Courier" size="12" spacebefore="1.0" align="right" leftindent="1016" rightindent="127" allcaps="1"/>
+    <style name="Shot" builtin="1" builtin_index="7" label="Shot" basestylename="Normal Text" style_enter="Action" style_tab_after="Action" font="Courier" size="12" spacebefore="1.0" keepwithnext="1" allcaps="1"/>
+    <header_style basestylename="Normal Text"/>
+    <footer_style basestylename="Normal Text"/>
+  `
+
+// styleName returns the paragraph's style name
+func (p *osfParagraph) styleName() string {
+	if p.Style == nil {
+		return ""
+	}
+	if p.Style.BaseStyleName != "" {
+		return p.Style.BaseStyleName
+	}
+	return p.Style.Name
+}
+
+// align returns the paragraph's alignment, "left" if not set
+func (p *osfParagraph) align() string {
+	if p.Style == nil || p.Style.Align == "" {
+		return "left"
+	}
+	return strings.ToLower(p.Style.Align)
+}
+
+// text returns the plain text of a paragraph
+func (p *osfParagraph) text() string {
+	out := []string{}
+	for _, t := range p.Text {
+		out = append(out, t.Value)
+	}
+	return strings.Join(out, "")
+}
+
+// spans returns the paragraph's text runs as spans
+func (p *osfParagraph) spans() []*Span {
+	spans := []*Span{}
+	for _, t := range p.Text {
+		if t.Value != "" {
+			spans = append(spans, styledSpan(t.Value, t.Bold == "1", t.Italic == "1", t.Underline == "1"))
+		}
+	}
+	return spans
+}
+
+// osfElement converts a paragraph into an element, it returns nil for
+// empty paragraphs. OSF stores parentheticals without the parentheses.
+func osfElement(p *osfParagraph) *Element {
+	elemType, ok := osfTypes[p.styleName()]
+	if !ok {
+		elemType = GeneralTextType
+	}
+	spans := p.spans()
+	if elemType == ParentheticalType && len(spans) > 0 && !strings.HasPrefix(strings.TrimSpace(p.text()), "(") {
+		spans = append(append([]*Span{{Text: "("}}, spans...), &Span{Text: ")"})
+	}
+	return importedElement(elemType, p.align() == "center", spans, p.Number)
+}
+
+// ParseOSF takes an Open Screenplay Format (OSF) XML document and returns
+// a Fountain struct. Paragraph styles are mapped onto the element types
+// (e.g. "Scene Heading" to SceneHeadingType, "Action" to ActionType).
+func ParseOSF(src []byte) (*Fountain, error) {
+	osf := new(osfDocument)
+	if err := xml.Unmarshal(src, osf); err != nil {
+		return nil, err
+	}
+	document := new(Fountain)
+	if osf.TitlePage != nil {
+		paragraphs := []*alignedText{}
+		for _, p := range osf.TitlePage.Paragraphs {
+			paragraphs = append(paragraphs, &alignedText{align: p.align(), text: p.text()})
+		}
+		document.TitlePage = inferTitlePage(paragraphs)
+	}
+	if osf.Paragraphs == nil {
+		return document, nil
+	}
+	elements := []*Element{}
+	for _, p := range osf.Paragraphs.Paragraphs {
+		if p.Style != nil && p.Style.PageBreakBefore == "1" && len(elements) > 0 {
+			elements = appendElement(elements, newPageFeed())
+		}
+		if element := osfElement(p); element != nil {
+			elements = appendElement(elements, element)
+		}
+	}
+	document.Elements = elements
+	return document, nil
+}
+
+// ParseOSFFile takes a filename of an Open Screenplay Format document
+// and returns a Fountain struct.
+func ParseOSFFile(fname string) (*Fountain, error) {
+	src, err := os.ReadFile(fname)
+	if err != nil {
+		return nil, err
+	}
+	return ParseOSF(src)
+}
+
+// osfTextRuns converts styled runs into OSF text runs
+func osfTextRuns(runs []*layoutRun) []*osfText {
+	texts := []*osfText{}
+	flag := func(style int, bit int) string {
+		if style&bit != 0 {
+			return "1"
+		}
+		return ""
+	}
+	for _, run := range runs {
+		texts = append(texts, &osfText{
+			Bold:      flag(run.style, runBold),
+			Italic:    flag(run.style, runItalic),
+			Underline: flag(run.style, runUnderline),
+			Value:     run.text,
+		})
+	}
+	return texts
+}
+
+// osfParagraphsFor converts an element into OSF paragraphs. Dual dialogue
+// is written as the two speeches one after the other.
+func osfParagraphsFor(element *Element) []*osfParagraph {
+	if element.Type == DualDialogueType {
+		paragraphs := []*osfParagraph{}
+		for _, elem := range element.Elements {
+			paragraphs = append(paragraphs, osfParagraphsFor(elem)...)
+		}
+		return paragraphs
+	}
+	name, align := exportStyle(element)
+	if name == "" {
+		return nil
+	}
+	if name == "General" {
+		name = "Normal Text"
+	}
+	runs := exportRuns(element)
+	if element.Type == ParentheticalType && len(runs) > 0 {
+		first, last := runs[0], runs[len(runs)-1]
+		first.text = strings.TrimPrefix(first.text, "(")
+		last.text = strings.TrimSuffix(last.text, ")")
+	}
+	if len(runs) == 0 {
+		return nil
+	}
+	p := &osfParagraph{Style: &osfStyle{BaseStyleName: name, Align: align}, Text: osfTextRuns(runs)}
+	if element.Type == SceneHeadingType {
+		p.Number = element.SceneNumber
+	}
+	return []*osfParagraph{p}
+}
+
+// ToOSF renders a Fountain document as an Open Screenplay Format (OSF)
+// XML document. Notes, sections and synopses are not part of the format
+// and are left out.
+func (doc *Fountain) ToOSF() ([]byte, error) {
+	osf := new(osfDocument)
+	osf.Type = "Open Screenplay Format document"
+	osf.Version = "30"
+	osf.Styles = &osfStyles{Definitions: osfStyleDefinitions}
+	osf.Paragraphs = new(osfParagraphs)
+	newPage := false
+	for _, element := range doc.Elements {
+		if element.Type == PageFeed {
+			newPage = len(osf.Paragraphs.Paragraphs) > 0
+			continue
+		}
+		paragraphs := osfParagraphsFor(element)
+		if len(paragraphs) == 0 {
+			continue
+		}
+		if newPage {
+			paragraphs[0].Style.PageBreakBefore, newPage = "1", false
+		}
+		osf.Paragraphs.Paragraphs = append(osf.Paragraphs.Paragraphs, paragraphs...)
+	}
+	if len(doc.TitlePage) > 0 {
+		osf.TitlePage = new(osfParagraphs)
+		for _, line := range titlePageLayout(doc.TitlePage) {
+			p := &osfParagraph{Style: &osfStyle{BaseStyleName: "Normal Text"}}
+			if line.align != "left" {
+				p.Style.Align = line.align
+			}
+			p.Text = osfTextRuns([]*layoutRun{{text: line.text, style: line.style}})
+			osf.TitlePage.Paragraphs = append(osf.TitlePage.Paragraphs, p)
+		}
+	}
+	src, err := xml.MarshalIndent(osf, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	buf := bytes.NewBufferString(`<?xml version="1.0" encoding="utf-8"?>` + "\n")
+	buf.Write(src)
+	buf.WriteString("\n")
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,69 @@
+package fountain
+
+import (
+	"path"
+	"testing"
+)
+
+func TestOSF(t *testing.T) {
+	src := []byte(`<?xml version="1.0" encoding="utf-8"?>
+<document type="Open Screenplay Format document" version="30">
+  <paragraphs>
+    <para><style basestylename="Transition"/><text>FADE IN:</text></para>
+    <para number="1"><style basestylename="Scene Heading"/><text>INT. KITCHEN - DAY</text></para>
+    <para><style basestylename="Action"/><text>Steam rises from a </text><text italic="1">hot</text><text> pot.</text></para>
+    <para><style basestylename="Character"/><text>BOB</text></para>
+    <para><style basestylename="Parenthetical"/><text>quietly</text></para>
+    <para><style basestylename="Dialogue"/><text>Careful.</text></para>
+    <para><style basestylename="Shot"/><text>CLOSE ON THE POT</text></para>
+    <para><style basestylename="Lyrics"/><text>Bubble, bubble.</text></para>
+    <para><style basestylename="Action" pagebreakbefore="1" align="center"/><text>THE END.</text></para>
+    <para><style name="Normal Text"/><text></text></para>
+  </paragraphs>
+</document>`)
+	doc, err := ParseOSF(src)
+	assertOK(t, err, "ParseOSF(src)")
+	expected := []struct {
+		Type    int
+		Content string
+		Forced  bool
+	}{
+		{TransitionType, "FADE IN:", true},
+		{SceneHeadingType, "INT. KITCHEN - DAY", false},
+		{ActionType, "Steam rises from a *hot* pot.", false},
+		{CharacterType, "BOB", false},
+		{ParentheticalType, "(quietly)", false},
+		{DialogueType, "Careful.", false},
+		{SceneHeadingType, "CLOSE ON THE POT", true},
+		{LyricType, "Bubble, bubble.", true},
+		{PageFeed, "===", false},
+		{CenterAlignment, ">THE END.<", false},
+	}
+	elements := []*Element{}
+	for _, element := range doc.Elements {
+		if element.Type != EmptyType {
+			elements = append(elements, element)
+		}
+	}
+	if len(elements) != len(expected) {
+		t.Fatalf("expected %d elements, got %d", len(expected), len(elements))
+	}
+	for i, e := range expected {
+		if elements[i].Type != e.Type || elements[i].Content != e.Content || elements[i].Forced != e.Forced {
+			t.Errorf("element %d: expected %s %q (forced %t), got %s %q (forced %t)", i, typeName(e.Type), e.Content, e.Forced, elements[i].TypeName(), elements[i].Content, elements[i].Forced)
+		}
+	}
+	if elements[1].SceneNumber != "1" {
+		t.Errorf("expected scene number 1, got %q", elements[1].SceneNumber)
+	}
+
+	doc, err = ParseFDXFile(path.Join("testdata", "sample-06.fdx"))
+	assertOK(t, err, "ParseFDXFile(testdata/sample-06.fdx)")
+	src, err = doc.ToOSF()
+	assertOK(t, err, "ToOSF()")
+	copied, err := ParseOSF(src)
+	assertOK(t, err, "ParseOSF(ToOSF())")
+	if expected, got := doc.String(), copied.String(); expected != got {
+		t.Errorf("round trip changed the screenplay, expected\n%s\ngot\n%s", expected, got)
+	}
+}
@@ -0,0 +1,130 @@
+// Package fountain is a Golang package supporting Fountain screenplay markup.
+//
+// outline.go builds the structure of a screenplay, its sections with
+// the scenes and synopses they hold, e.g. for working on a story
+// without reading the full script.
+package fountain
+
+import (
+	"strings"
+)
+
+// OutlineNode is a section, scene or synopsis in the outline. Start and
+// End are the range of the screenplay's Elements it covers (End is
+// excluded). A section runs until the next section at the same or a
+// higher level (fewer "#"), a scene until the next scene or section.
+type OutlineNode struct {
+	Type int    `json:"type" yaml:"type"`
+	Name string `json:"name" yaml:"name"`
+	// Text is the section title, scene heading or synopsis without
+	// the markup
+	Text string `json:"text" yaml:"text"`
+	// Depth is the section level, 1 for "#", 2 for "##", etc.
+	Depth int `json:"depth,omitempty" yaml:"depth,omitempty"`
+	// Number is the scene number or, if not numbered, the scene's
+	// position in the script counting from one.
+	Number   string         `json:"number,omitempty" yaml:"number,omitempty"`
+	Line     int            `json:"line,omitempty" yaml:"line,omitempty"`
+	Start    int            `json:"start" yaml:"start"`
+	End      int            `json:"end" yaml:"end"`
+	Children []*OutlineNode `json:"children,omitempty" yaml:"children,omitempty"`
+}
+
+// Outline holds the title of the screenplay and the outline tree. The
+// sections hold the sections below them and the scenes, the synopses
+// are held by the section or scene they follow.
+type Outline struct {
+	Title string         `json:"title,omitempty" yaml:"title,omitempty"`
+	Nodes []*OutlineNode `json:"nodes" yaml:"nodes"`
+}
+
+// plainLines returns the lines of s without their emphasis markup,
+// trimmed, with the prefix (e.g. "=") removed and joined with a space
+func plainLines(s string, prefix string) string {
+	lines := []string{}
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimPrefix(strings.TrimSpace(line), prefix)
+		if line = strings.TrimSpace(spansText(ParseEmphasis(line))); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, " ")
+}
+
+// newOutlineNode creates the node for the element at position i
+func newOutlineNode(element *Element, i int) *OutlineNode {
+	node := &OutlineNode{Type: element.Type, Name: typeName(element.Type), Start: i, End: i + 1}
+	if element.Start != nil {
+		node.Line = element.Start.Line
+	}
+	switch element.Type {
+	case SectionType:
+		node.Depth = max(element.Depth, 1)
+		node.Text = plainLines(strings.TrimLeft(strings.TrimSpace(element.Content), "#"), "")
+	case SynopsisType:
+		node.Text = plainLines(element.Content, "=")
+	default:
+		node.Text = strings.TrimSpace(plainText(element))
+	}
+	return node
+}
+
+// Outline returns the sections, scenes and synopses of the screenplay
+// as a tree. Sections nest by their depth, a "##" section following a
+// "#" section is held by it. Scenes and synopses before the first
+// section are at the top of the tree.
+func (doc *Fountain) Outline() *Outline {
+	outline := &Outline{Nodes: []*OutlineNode{}}
+	if title := ParseTitlePage(doc.TitlePage).Title; title != "" {
+		outline.Title = plainLines(title, "")
+	}
+	// sections holds the open sections, the innermost last
+	sections := []*OutlineNode{}
+	var scene, last *OutlineNode
+	add := func(node *OutlineNode) {
+		if len(sections) == 0 {
+			outline.Nodes = append(outline.Nodes, node)
+		} else {
+			parent := sections[len(sections)-1]
+			parent.Children = append(parent.Children, node)
+		}
+		last = node
+	}
+	endScene := func(end int) {
+		if scene != nil {
+			scene.End = end
+			scene = nil
+		}
+	}
+	ids := doc.sceneIDs()
+	for i, element := range doc.Elements {
+		switch {
+		case element.Type == SectionType:
+			endScene(i)
+			node := newOutlineNode(element, i)
+			for len(sections) > 0 && sections[len(sections)-1].Depth >= node.Depth {
+				sections[len(sections)-1].End = i
+				sections = sections[0 : len(sections)-1]
+			}
+			add(node)
+			sections = append(sections, node)
+		case isScene(element):
+			endScene(i)
+			scene = newOutlineNode(element, i)
+			scene.Number = ids[i]
+			add(scene)
+		case element.Type == SynopsisType:
+			node := newOutlineNode(element, i)
+			if last == nil {
+				outline.Nodes = append(outline.Nodes, node)
+			} else {
+				last.Children = append(last.Children, node)
+			}
+		}
+	}
+	endScene(len(doc.Elements))
+	for _, section := range sections {
+		section.End = len(doc.Elements)
+	}
+	return outline
+}
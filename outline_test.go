@@ -0,0 +1,103 @@
+package fountain
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// outlineString writes the outline nodes one per line, indented by
+// level, with their element range
+func outlineString(nodes []*OutlineNode, level int) string {
+	out := []string{}
+	for _, node := range nodes {
+		out = append(out, fmt.Sprintf("%s%s %d %s%s [%d,%d)", strings.Repeat("  ", level), node.Name, node.Depth, node.Number, node.Text, node.Start, node.End))
+		if len(node.Children) > 0 {
+			out = append(out, outlineString(node.Children, level+1))
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
+func TestOutline(t *testing.T) {
+	src := `Title: _The_ Test
+
+= A story about a test.
+
+# Act One
+## Sequence *One*
+= The setup.
+
+INT. HOUSE - DAY
+
+= Bob comes home.
+= He is tired.
+
+Bob enters.
+
+### Beat
+
+EXT. YARD - NIGHT #7#
+
+FADE IN:
+
+# Act Two
+
+INT. SHED - DAY
+`
+	doc, err := Parse([]byte(src))
+	assertOK(t, err, "Parse(src)")
+	if got := doc.RenderSource(nil); got != src {
+		t.Errorf("expected the source back, got %q", got)
+	}
+	depths := []int{}
+	for _, element := range doc.Elements {
+		if element.Type == SectionType {
+			depths = append(depths, element.Depth)
+		}
+	}
+	if fmt.Sprintf("%v", depths) != "[1 2 3 1]" {
+		t.Errorf("expected each section line with its depth, got %v", depths)
+	}
+
+	outline := doc.Outline()
+	if outline.Title != "The Test" {
+		t.Errorf("expected the title The Test, got %q", outline.Title)
+	}
+	expected := `Synopsis 0 A story about a test. [1,2)
+Section 1 Act One [3,19)
+  Section 2 Sequence One [4,19)
+    Synopsis 0 The setup. [5,6)
+    Scene Heading 0 1INT. HOUSE - DAY [7,13)
+      Synopsis 0 Bob comes home. He is tired. [9,10)
+    Section 3 Beat [13,19)
+      Scene Heading 0 7EXT. YARD - NIGHT [15,19)
+Section 1 Act Two [19,22)
+  Scene Heading 0 3INT. SHED - DAY [21,22)`
+	if got := outlineString(outline.Nodes, 0); got != expected {
+		for i, element := range doc.Elements {
+			t.Logf("%d %s %q", i, element.TypeName(), element.Content)
+		}
+		t.Errorf("expected\n%s\ngot\n%s", expected, got)
+	}
+
+	// Sections read from JSON get their depth back
+	src2, err := doc.ToJSON()
+	assertOK(t, err, "doc.ToJSON()")
+	doc2, err := FromJSON(src2)
+	assertOK(t, err, "FromJSON(src)")
+	if got := outlineString(doc2.Outline().Nodes, 0); got != expected {
+		t.Errorf("expected the same outline from JSON, got\n%s", got)
+	}
+
+	// Scenes before the first section are at the top
+	doc, err = Parse([]byte("INT. HOUSE - DAY\n\n= Bob.\n\n## Later\n\nEXT. YARD - DAY\n"))
+	assertOK(t, err, "Parse(src)")
+	expected = `Scene Heading 0 1INT. HOUSE - DAY [0,4)
+  Synopsis 0 Bob. [2,3)
+Section 2 Later [4,7)
+  Scene Heading 0 2EXT. YARD - DAY [6,7)`
+	if got := outlineString(doc.Outline().Nodes, 0); got != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, got)
+	}
+}
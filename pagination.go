@@ -0,0 +1,159 @@
+// Package fountain is a Golang package supporting Fountain screenplay markup.
+//
+// pagination.go reports the page count, page breaks and scene lengths in
+// eighths of a page (e.g. for scheduling).
+package fountain
+
+import (
+	"fmt"
+)
+
+// Page describes a page of the laid out script. Line is the source line
+// of the first element on the page and Scene the scene at the top of
+// the page (empty before the first scene).
+type Page struct {
+	Number int    `json:"number" yaml:"number"`
+	Lines  int    `json:"lines" yaml:"lines"`
+	Line   int    `json:"line,omitempty" yaml:"line,omitempty"`
+	Scene  string `json:"scene,omitempty" yaml:"scene,omitempty"`
+}
+
+// SceneLength holds the length of a scene. Lines counts the printed
+// lines including the blank lines between elements, Eighths is the
+// length in eighths of a page rounded up (at least 1/8) and Length is
+// written the way Final Draft does, e.g. "3/8", "1" or "1 2/8".
+type SceneLength struct {
+	Number  string `json:"number,omitempty" yaml:"number,omitempty"`
+	Heading string `json:"heading" yaml:"heading"`
+	Page    int    `json:"page" yaml:"page"`
+	Lines   int    `json:"lines" yaml:"lines"`
+	Eighths int    `json:"eighths" yaml:"eighths"`
+	Length  string `json:"length" yaml:"length"`
+}
+
+// Pagination holds the page count, the pages and the scene lengths of
+// a screenplay laid out on a paper size.
+type Pagination struct {
+	PaperSize    string         `json:"paper_size" yaml:"paper_size"`
+	LinesPerPage int            `json:"lines_per_page" yaml:"lines_per_page"`
+	PageCount    int            `json:"page_count" yaml:"page_count"`
+	Pages        []*Page        `json:"pages" yaml:"pages"`
+	Scenes       []*SceneLength `json:"scenes" yaml:"scenes"`
+}
+
+// Eighths converts a number of lines into eighths of a page, rounding
+// up so a scene is at least 1/8 of a page.
+func Eighths(lines int, linesPerPage int) int {
+	if lines <= 0 || linesPerPage <= 0 {
+		return 0
+	}
+	eighths := (lines*8 + linesPerPage - 1) / linesPerPage
+	if eighths < 1 {
+		eighths = 1
+	}
+	return eighths
+}
+
+// FormatEighths writes eighths of a page as whole pages and eighths,
+// e.g. 3 is "3/8", 8 is "1" and 10 is "1 2/8".
+func FormatEighths(eighths int) string {
+	pages, rest := eighths/8, eighths%8
+	switch {
+	case pages == 0:
+		return fmt.Sprintf("%d/8", rest)
+	case rest == 0:
+		return fmt.Sprintf("%d", pages)
+	default:
+		return fmt.Sprintf("%d %d/8", pages, rest)
+	}
+}
+
+// sceneIndex maps the elements (including those held in dual dialogue)
+// to the index of the scene they belong to, -1 before the first scene.
+func (doc *Fountain) sceneIndex() map[*Element]int {
+	index := map[*Element]int{}
+	scene := -1
+	for _, element := range doc.Elements {
+		if isScene(element) {
+			scene++
+		}
+		index[element] = scene
+		for _, elem := range element.Elements {
+			index[elem] = scene
+		}
+	}
+	return index
+}
+
+// Paginate lays out the script using the standard screenplay format
+// (Courier 12pt, standard margins and indents) on the paper size,
+// US Letter if paper is nil. The layout does not depend on MaxWidth
+// so the page count and scene lengths stay close to those of Final
+// Draft and the PDF renderer. The title page is not counted.
+func (doc *Fountain) Paginate(paper *PaperSize) *Pagination {
+	if paper == nil {
+		paper = LetterPaper
+	}
+	pagination := new(Pagination)
+	pagination.PaperSize = paper.Name
+	pagination.LinesPerPage = paper.linesPerPage()
+	pagination.Pages = []*Page{}
+	pagination.Scenes = []*SceneLength{}
+	ids := doc.sceneIDs()
+	for i, element := range doc.Elements {
+		if scene := ParseSceneHeading(element); scene != nil {
+			pagination.Scenes = append(pagination.Scenes, &SceneLength{Number: ids[i], Heading: scene.Heading})
+		}
+	}
+	index := doc.sceneIndex()
+	sceneOf := func(line *layoutLine, current int) int {
+		if scene, ok := index[line.element]; ok {
+			return scene
+		}
+		return current
+	}
+	current := -1
+	pages := layoutScript(paper, doc.Elements)
+	for _, lp := range pages {
+		page := &Page{Number: lp.number, Lines: len(lp.lines)}
+		// Blank lines count towards the scene of the line that follows,
+		// those at the bottom of the page towards the last scene
+		owners := make([]int, len(lp.lines))
+		next := current
+		for _, line := range lp.lines {
+			if line != nil {
+				next = sceneOf(line, next)
+			}
+		}
+		for i := len(lp.lines) - 1; i >= 0; i-- {
+			if line := lp.lines[i]; line != nil {
+				next = sceneOf(line, next)
+			}
+			owners[i] = next
+		}
+		if len(owners) > 0 && owners[0] >= 0 {
+			page.Scene = pagination.Scenes[owners[0]].Number
+		}
+		for i, line := range lp.lines {
+			if page.Line == 0 && line != nil && line.element != nil && line.element.Start != nil {
+				page.Line = line.element.Start.Line
+			}
+			if owners[i] != current {
+				current = owners[i]
+				if current >= 0 && pagination.Scenes[current].Page == 0 {
+					pagination.Scenes[current].Page = lp.number
+				}
+			}
+			if current >= 0 {
+				pagination.Scenes[current].Lines++
+			}
+		}
+		pagination.Pages = append(pagination.Pages, page)
+	}
+	pagination.PageCount = len(pagination.Pages)
+	for _, scene := range pagination.Scenes {
+		scene.Eighths = Eighths(scene.Lines, pagination.LinesPerPage)
+		scene.Length = FormatEighths(scene.Eighths)
+	}
+	return pagination
+}
@@ -0,0 +1,89 @@
+package fountain
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestEighths(t *testing.T) {
+	for _, test := range []struct {
+		lines    int
+		expected string
+	}{
+		{1, "1/8"},
+		{6, "1/8"},
+		{7, "2/8"},
+		{27, "4/8"},
+		{54, "1"},
+		{55, "1 1/8"},
+		{70, "1 3/8"},
+	} {
+		if got := FormatEighths(Eighths(test.lines, 54)); got != test.expected {
+			t.Errorf("%d lines: expected %q, got %q", test.lines, test.expected, got)
+		}
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	// Compare the scene lengths with those Final Draft worked out for
+	// the same screenplays, allowing for an eighth difference in layout.
+	reLength := regexp.MustCompile(`<SceneProperties Length="(\d+)/8" Page="(\d+)"`)
+	for i := 1; i <= 6; i++ {
+		fname := path.Join("testdata", fmt.Sprintf("sample-%02d.fountain", i))
+		doc, err := ParseFile(fname)
+		assertOK(t, err, "ParseFile("+fname+")")
+		fdx, err := os.ReadFile(strings.TrimSuffix(fname, ".fountain") + ".fdx")
+		assertOK(t, err, "ReadFile(fdx)")
+		expected := reLength.FindAllStringSubmatch(string(fdx), -1)
+		pagination := doc.Paginate(nil)
+		if len(pagination.Scenes) != len(expected) {
+			t.Errorf("%s: expected %d scenes, got %d", fname, len(expected), len(pagination.Scenes))
+			continue
+		}
+		for j, scene := range pagination.Scenes {
+			var eighths, page int
+			fmt.Sscanf(expected[j][1], "%d", &eighths)
+			fmt.Sscanf(expected[j][2], "%d", &page)
+			if d := scene.Eighths - eighths; d < -1 || d > 1 || scene.Page != page {
+				t.Errorf("%s: scene %s expected %d/8 on page %d, got %s on page %d", fname, scene.Number, eighths, page, scene.Length, scene.Page)
+			}
+		}
+	}
+
+	src := []string{"INT. ROOM - DAY", ""}
+	for i := 0; i < 60; i++ {
+		src = append(src, fmt.Sprintf("Action line number %d.", i), "")
+	}
+	src = append(src, "EXT. STREET - NIGHT", "", "Rain.")
+	doc, err := Parse([]byte(strings.Join(src, "\n")))
+	assertOK(t, err, "Parse(src)")
+	maxWidth := MaxWidth
+	MaxWidth = 20
+	pagination := doc.Paginate(LetterPaper)
+	MaxWidth = maxWidth
+	if pagination.PageCount != 3 || pagination.LinesPerPage != 54 {
+		t.Fatalf("expected 3 pages of 54 lines, got %d pages of %d lines", pagination.PageCount, pagination.LinesPerPage)
+	}
+	if page := pagination.Pages[1]; page.Scene != "1" || page.Line == 0 {
+		t.Errorf("expected page 2 to continue scene 1, got %+v", page)
+	}
+	room, street := pagination.Scenes[0], pagination.Scenes[1]
+	// heading and 60 action lines separated by blank lines, less the
+	// blank lines at the top of pages 2 and 3
+	if room.Lines != 119 || room.Length != "2 2/8" || room.Page != 1 {
+		t.Errorf("unexpected length for scene 1 %+v", room)
+	}
+	if street.Page != 3 || street.Length != "1/8" {
+		t.Errorf("unexpected length for scene 2 %+v", street)
+	}
+	fdx, err := doc.ToFDX()
+	assertOK(t, err, "ToFDX()")
+	if !bytes.Contains(fdx, []byte(`<SceneProperties Length="2 2/8" Page="1" Title="">`)) {
+		t.Errorf("expected scene properties in\n%s", fdx)
+	}
+}
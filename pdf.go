@@ -0,0 +1,215 @@
+// Package fountain is a Golang package supporting Fountain screenplay markup.
+//
+// pdf.go renders a screenplay as PDF using the standard Courier fonts
+// built into PDF readers so no font embedding is needed.
+package fountain
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// pdfFonts maps run styles to the standard PDF font resources
+var pdfFonts = []struct {
+	name     string
+	baseFont string
+}{
+	{"F1", "Courier"},
+	{"F2", "Courier-Bold"},
+	{"F3", "Courier-Oblique"},
+	{"F4", "Courier-BoldOblique"},
+}
+
+// winAnsi maps common typographic characters outside Latin-1 to their
+// WinAnsiEncoding codes.
+var winAnsi = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86,
+	'‡': 0x87, 'ˆ': 0x88, '‰': 0x89, 'Š': 0x8a, '‹': 0x8b, 'Œ': 0x8c,
+	'Ž': 0x8e, '‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95,
+	'–': 0x96, '—': 0x97, '˜': 0x98, '™': 0x99, 'š': 0x9a, '›': 0x9b,
+	'œ': 0x9c, 'ž': 0x9e, 'Ÿ': 0x9f,
+}
+
+// pdfString escapes text as a PDF literal string in WinAnsiEncoding
+func pdfString(s string) string {
+	buf := []byte{'('}
+	for _, r := range s {
+		var c byte
+		if code, ok := winAnsi[r]; ok {
+			c = code
+		} else if r < 256 {
+			c = byte(r)
+		} else {
+			c = '?'
+		}
+		switch {
+		case c == '(' || c == ')' || c == '\\':
+			buf = append(buf, '\\', c)
+		case c < 32 || c > 126:
+			buf = append(buf, []byte(fmt.Sprintf("\\%03o", c))...)
+		default:
+			buf = append(buf, c)
+		}
+	}
+	return string(append(buf, ')'))
+}
+
+// pdfFontFor returns the font resource name for a run style
+func pdfFontFor(style int) string {
+	i := 0
+	if style&runBold != 0 {
+		i++
+	}
+	if style&runItalic != 0 {
+		i += 2
+	}
+	return pdfFonts[i].name
+}
+
+// pdfPageContent renders the lines of a page as a PDF content stream.
+// Positions are in inches from the top left, PDF uses points from the
+// bottom left.
+func pdfPageContent(paper *PaperSize, page *layoutPage, pageLabel string) []byte {
+	buf := new(bytes.Buffer)
+	top := (paper.Height - paper.Top) * 72
+	fontSize := 12.0
+	lineHeight := 72.0 / linesPerInch
+	drawText := func(x float64, y float64, runs []*layoutRun) {
+		pos := x * 72
+		for _, run := range runs {
+			if run.text == "" {
+				continue
+			}
+			fmt.Fprintf(buf, "BT /%s %.0f Tf %.2f %.2f Td %s Tj ET\n", pdfFontFor(run.style), fontSize, pos, y, pdfString(run.text))
+			width := float64(len([]rune(run.text))) * 72 / charsPerInch
+			if run.style&runUnderline != 0 {
+				fmt.Fprintf(buf, "0.5 w %.2f %.2f m %.2f %.2f l S\n", pos, y-2, pos+width, y-2)
+			}
+			pos += width
+		}
+	}
+	if pageLabel != "" {
+		// Page numbers go in the top right corner
+		x := paper.rightEdge() - float64(len(pageLabel))/charsPerInch
+		drawText(x, (paper.Height-paper.Top/2)*72-fontSize, []*layoutRun{{text: pageLabel}})
+	}
+	for i, line := range page.lines {
+		if line == nil {
+			continue
+		}
+		y := top - float64(i+1)*lineHeight + 3
+		for _, chunk := range line.chunks {
+			drawText(chunk.x, y, chunk.runs)
+		}
+	}
+	return buf.Bytes()
+}
+
+// pdfWriter assembles the PDF objects and cross reference table
+type pdfWriter struct {
+	buf     *bytes.Buffer
+	offsets []int
+}
+
+// addObject writes an object returning its object number
+func (w *pdfWriter) addObject(body string) int {
+	w.offsets = append(w.offsets, w.buf.Len())
+	n := len(w.offsets)
+	fmt.Fprintf(w.buf, "%d 0 obj\n%s\nendobj\n", n, body)
+	return n
+}
+
+// reserve reserves an object number to be written later with setObject
+func (w *pdfWriter) reserve() int {
+	w.offsets = append(w.offsets, -1)
+	return len(w.offsets)
+}
+
+// setObject writes a previously reserved object
+func (w *pdfWriter) setObject(n int, body string) {
+	w.offsets[n-1] = w.buf.Len()
+	fmt.Fprintf(w.buf, "%d 0 obj\n%s\nendobj\n", n, body)
+}
+
+// addStream writes a stream object returning its object number
+func (w *pdfWriter) addStream(content []byte) int {
+	return w.addObject(fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content))
+}
+
+// titleOf returns the screenplay title from the title page, if any
+func (doc *Fountain) titleOf() string {
+	for _, element := range doc.TitlePage {
+		if strings.ToLower(strings.TrimSpace(element.Name)) == "title" {
+			lines := []string{}
+			for _, line := range titlePageValue(element) {
+				lines = append(lines, string(toRunes(line)))
+			}
+			return strings.Join(lines, " ")
+		}
+	}
+	return ""
+}
+
+// toRunes returns the characters of styled text
+func toRunes(chars []styledChar) []rune {
+	out := make([]rune, len(chars))
+	for i, c := range chars {
+		out[i] = c.r
+	}
+	return out
+}
+
+// ToPDF renders a Fountain document as a PDF using the package defaults.
+func (doc *Fountain) ToPDF() ([]byte, error) {
+	return doc.RenderPDF(nil)
+}
+
+// RenderPDF renders a Fountain document as a PDF in the standard
+// screenplay format. The title page is laid out from the TitlePage
+// elements, page numbers start on the second script page and PageFeed
+// elements start a new page. If opt is nil the package defaults are used.
+func (doc *Fountain) RenderPDF(opt *Options) ([]byte, error) {
+	opt = options(opt)
+	paper := getPaperSize(opt.PaperSize)
+
+	w := &pdfWriter{buf: new(bytes.Buffer)}
+	w.buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	catalog := w.reserve()
+	pagesObj := w.reserve()
+	fonts := []string{}
+	for _, font := range pdfFonts {
+		n := w.addObject(fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", font.baseFont))
+		fonts = append(fonts, fmt.Sprintf("/%s %d 0 R", font.name, n))
+	}
+	resources := fmt.Sprintf("<< /Font << %s >> >>", strings.Join(fonts, " "))
+	mediaBox := fmt.Sprintf("[0 0 %.2f %.2f]", paper.Width*72, paper.Height*72)
+
+	kids := []string{}
+	addPage := func(content []byte) {
+		stream := w.addStream(content)
+		n := w.addObject(fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox %s /Resources %s /Contents %d 0 R >>", pagesObj, mediaBox, resources, stream))
+		kids = append(kids, fmt.Sprintf("%d 0 R", n))
+	}
+	if titlePage := layoutTitlePage(paper, doc.TitlePage); titlePage != nil {
+		addPage(pdfPageContent(paper, titlePage, ""))
+	}
+	for _, page := range layoutScript(paper, doc.Elements) {
+		label := ""
+		if page.number > 1 {
+			label = fmt.Sprintf("%d.", page.number)
+		}
+		addPage(pdfPageContent(paper, page, label))
+	}
+	w.setObject(pagesObj, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(kids)))
+	w.setObject(catalog, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pagesObj))
+	info := w.addObject(fmt.Sprintf("<< /Title %s /Producer (fountain %s) >>", pdfString(doc.titleOf()), Version))
+
+	xref := w.buf.Len()
+	fmt.Fprintf(w.buf, "xref\n0 %d\n0000000000 65535 f \n", len(w.offsets)+1)
+	for _, offset := range w.offsets {
+		fmt.Fprintf(w.buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(w.buf, "trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(w.offsets)+1, catalog, info, xref)
+	return w.buf.Bytes(), nil
+}
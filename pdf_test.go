@@ -0,0 +1,94 @@
+package fountain
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestPDF(t *testing.T) {
+	screenplay, err := ParseFile(path.Join("testdata", "sample-02.fountain"))
+	assertOK(t, err, "ParseFile(testdata/sample-02.fountain)")
+	for _, paper := range []string{"letter", "a4"} {
+		opt := DefaultOptions()
+		opt.PaperSize = paper
+		src, err := screenplay.RenderPDF(opt)
+		assertOK(t, err, "RenderPDF()")
+		if !bytes.HasPrefix(src, []byte("%PDF-1.4")) || !bytes.HasSuffix(src, []byte("%%EOF\n")) {
+			t.Fatalf("expected a PDF document for %s", paper)
+		}
+		// Check the cross reference table points at the objects
+		m := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(src)
+		if m == nil {
+			t.Fatalf("missing startxref")
+		}
+		xref, _ := strconv.Atoi(string(m[1]))
+		lines := strings.Split(string(src[xref:]), "\n")
+		for i, line := range lines[3:] {
+			if strings.HasPrefix(line, "trailer") {
+				break
+			}
+			offset, _ := strconv.Atoi(line[0:10])
+			if !bytes.HasPrefix(src[offset:], []byte(fmt.Sprintf("%d 0 obj", i+1))) {
+				t.Errorf("xref entry %d does not point to its object", i+1)
+			}
+		}
+		if !bytes.Contains(src, []byte("(TITLE)")) {
+			t.Errorf("expected title page in PDF")
+		}
+	}
+}
+
+func TestPagination(t *testing.T) {
+	src := []string{"INT. ROOM - DAY", ""}
+	for i := 0; i < 20; i++ {
+		src = append(src, fmt.Sprintf("Action line number %d.", i), "")
+	}
+	speech := []string{}
+	for i := 0; i < 40; i++ {
+		speech = append(speech, fmt.Sprintf("Sentence %d is said by Bob.", i))
+	}
+	src = append(src, "BOB", "(quietly)", strings.Join(speech, " "), "", "===", "", "EXT. STREET - NIGHT", "", "Rain.")
+	doc, err := Parse([]byte(strings.Join(src, "\n")))
+	assertOK(t, err, "Parse(src)")
+	paper := LetterPaper
+	pages := layoutScript(paper, doc.Elements)
+	if len(pages) != 3 {
+		t.Fatalf("expected 3 pages, got %d", len(pages))
+	}
+	text := func(line *layoutLine) string {
+		if line == nil || len(line.chunks) == 0 || len(line.chunks[0].runs) == 0 {
+			return ""
+		}
+		return line.chunks[0].runs[0].text
+	}
+	for _, page := range pages {
+		if len(page.lines) > paper.linesPerPage() {
+			t.Errorf("page %d has %d lines, expected at most %d", page.number, len(page.lines), paper.linesPerPage())
+		}
+	}
+	if s := text(pages[0].lines[len(pages[0].lines)-1]); s != "(MORE)" {
+		t.Errorf("expected page 1 to end with (MORE), got %q", s)
+	}
+	if s := text(pages[1].lines[0]); s != "BOB (CONT'D)" {
+		t.Errorf("expected page 2 to start with BOB (CONT'D), got %q", s)
+	}
+	// The page feed starts a new page
+	if s := text(pages[2].lines[0]); s != "EXT. STREET - NIGHT" {
+		t.Errorf("expected page 3 to start with the scene heading, got %q", s)
+	}
+
+	// A heading kept with a speech that can't fit on a short page is
+	// put on a page of its own
+	paper = &PaperSize{Name: "short", Width: 8.5, Height: 2.5, Top: 1, Bottom: 1, Left: 1.5, Right: 1}
+	doc, err = Parse([]byte("INT. ROOM - DAY\n\nBOB\nHi.\n"))
+	assertOK(t, err, "Parse(src)")
+	pages = layoutScript(paper, doc.Elements)
+	if len(pages) != 2 || text(pages[0].lines[0]) != "INT. ROOM - DAY" || len(pages[0].lines) != 1 {
+		t.Errorf("expected the heading alone on page 1 of 2, got %d pages", len(pages))
+	}
+}
@@ -0,0 +1,173 @@
+// Package fountain is a Golang package supporting Fountain screenplay markup.
+//
+// scenes.go breaks the scene headings down into setting, location and
+// time of day (e.g. for scheduling).
+package fountain
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// reHeadingSeparator matches the dashes separating the parts of a
+	// scene heading, e.g. "INT. HOUSE - KITCHEN -- NIGHT"
+	reHeadingSeparator = regexp.MustCompile(`\s+-{1,2}\s+`)
+
+	// dayTimes and nightTimes are the words used to count day and
+	// night scenes
+	dayTimes   = []string{"DAY", "MORNING", "AFTERNOON", "DAWN", "SUNRISE", "NOON", "DAYTIME"}
+	nightTimes = []string{"NIGHT", "EVENING", "DUSK", "SUNSET", "MIDNIGHT", "TWILIGHT", "NIGHTTIME"}
+	// otherTimes are time of day words that don't say day or night
+	otherTimes = []string{"CONTINUOUS", "LATER", "SAME", "MOMENTS", "CONT'D", "CONTD"}
+)
+
+// Scene holds the broken down scene heading and the characters that
+// speak in the scene. Number is the scene number or, if not numbered,
+// the scene's position in the script counting from one.
+type Scene struct {
+	Number      string   `json:"number,omitempty" yaml:"number,omitempty"`
+	Heading     string   `json:"heading" yaml:"heading"`
+	Setting     string   `json:"setting,omitempty" yaml:"setting,omitempty"`
+	Location    string   `json:"location,omitempty" yaml:"location,omitempty"`
+	SubLocation string   `json:"sub_location,omitempty" yaml:"sub_location,omitempty"`
+	TimeOfDay   string   `json:"time_of_day,omitempty" yaml:"time_of_day,omitempty"`
+	Line        int      `json:"line,omitempty" yaml:"line,omitempty"`
+	Characters  []string `json:"characters,omitempty" yaml:"characters,omitempty"`
+}
+
+// LocationCount holds the number of scenes set in a location
+type LocationCount struct {
+	Location string `json:"location" yaml:"location"`
+	Scenes   int    `json:"scenes" yaml:"scenes"`
+	Day      int    `json:"day" yaml:"day"`
+	Night    int    `json:"night" yaml:"night"`
+}
+
+// SceneBreakdown holds the scene list with the location totals and
+// the day and night counts.
+type SceneBreakdown struct {
+	Scenes    []*Scene         `json:"scenes" yaml:"scenes"`
+	Locations []*LocationCount `json:"locations" yaml:"locations"`
+	Day       int              `json:"day" yaml:"day"`
+	Night     int              `json:"night" yaml:"night"`
+	Other     int              `json:"other" yaml:"other"`
+}
+
+// isTimeOfDay returns true if value uses one of the words in times
+func isTimeOfDay(value string, times []string) bool {
+	for _, word := range strings.FieldsFunc(strings.ToUpper(value), func(r rune) bool {
+		return !(r == '\'' || (r >= 'A' && r <= 'Z'))
+	}) {
+		if containsString(times, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseSceneHeading splits a scene heading into setting ("INT", "EXT"
+// or "I/E"), location, sub-location and time of day. E.g.
+// "INT. HOUSE - KITCHEN - NIGHT" has the location "HOUSE", the
+// sub-location "KITCHEN" and the time of day "NIGHT". It returns nil
+// if the element is not a scene heading.
+func ParseSceneHeading(element *Element) *Scene {
+	if !isScene(element) {
+		return nil
+	}
+	scene := new(Scene)
+	heading := strings.TrimSpace(plainText(element))
+	scene.Heading = heading
+	if element.Start != nil {
+		scene.Line = element.Start.Line
+	}
+	if strings.HasPrefix(heading, ".") && !strings.HasPrefix(heading, "..") {
+		heading = strings.TrimSpace(heading[1:])
+	}
+	if m := reSetting.FindStringSubmatch(heading); m != nil {
+		switch setting := strings.ToUpper(strings.Join(strings.Fields(m[1]), "")); setting {
+		case "INT", "EXT":
+			scene.Setting = setting
+		case "EST":
+			scene.Setting = "EXT"
+		default:
+			scene.Setting = "I/E"
+		}
+		heading = heading[len(m[0]):]
+	}
+	parts := reHeadingSeparator.Split(strings.TrimSpace(heading), -1)
+	if len(parts) > 1 {
+		last := strings.TrimSpace(parts[len(parts)-1])
+		if isTimeOfDay(last, dayTimes) || isTimeOfDay(last, nightTimes) || isTimeOfDay(last, otherTimes) {
+			scene.TimeOfDay = last
+			parts = parts[0 : len(parts)-1]
+		}
+	}
+	scene.Location = strings.TrimSpace(parts[0])
+	if len(parts) > 1 {
+		scene.SubLocation = strings.TrimSpace(strings.Join(parts[1:], " - "))
+	}
+	return scene
+}
+
+// Scenes returns the scenes of the screenplay in order with the
+// characters that speak in each scene.
+func (doc *Fountain) Scenes() []*Scene {
+	scenes := []*Scene{}
+	var scene *Scene
+	addCharacter := func(element *Element) {
+		if name := CharacterName(element); scene != nil && name != "" && !containsString(scene.Characters, name) {
+			scene.Characters = append(scene.Characters, name)
+		}
+	}
+	for i, id := range doc.sceneIDs() {
+		element := doc.Elements[i]
+		switch element.Type {
+		case SceneHeadingType:
+			if s := ParseSceneHeading(element); s != nil {
+				scene = s
+				scene.Number = id
+				scenes = append(scenes, scene)
+			}
+		case CharacterType:
+			addCharacter(element)
+		case DualDialogueType:
+			for _, elem := range element.Elements {
+				addCharacter(elem)
+			}
+		}
+	}
+	return scenes
+}
+
+// SceneBreakdown returns the scene list with the number of scenes
+// set in each location (in order of first appearance) and the number
+// of day and night scenes. Scenes without a time of day or with one
+// like "CONTINUOUS" are counted as other.
+func (doc *Fountain) SceneBreakdown() *SceneBreakdown {
+	breakdown := new(SceneBreakdown)
+	breakdown.Scenes = doc.Scenes()
+	breakdown.Locations = []*LocationCount{}
+	byLocation := map[string]*LocationCount{}
+	for _, scene := range breakdown.Scenes {
+		key := strings.ToUpper(scene.Location)
+		location, ok := byLocation[key]
+		if !ok {
+			location = &LocationCount{Location: scene.Location}
+			byLocation[key] = location
+			breakdown.Locations = append(breakdown.Locations, location)
+		}
+		location.Scenes++
+		switch {
+		case isTimeOfDay(scene.TimeOfDay, nightTimes):
+			location.Night++
+			breakdown.Night++
+		case isTimeOfDay(scene.TimeOfDay, dayTimes):
+			location.Day++
+			breakdown.Day++
+		default:
+			breakdown.Other++
+		}
+	}
+	return breakdown
+}
@@ -0,0 +1,91 @@
+package fountain
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseSceneHeading(t *testing.T) {
+	for _, test := range []struct {
+		heading     string
+		setting     string
+		location    string
+		subLocation string
+		timeOfDay   string
+	}{
+		{"INT. KITCHEN - DAY", "INT", "KITCHEN", "", "DAY"},
+		{"EXT. AVENUE FOCH -- LAMPERT APARTMENT HOUSE -- DAY", "EXT", "AVENUE FOCH", "LAMPERT APARTMENT HOUSE", "DAY"},
+		{"INT./EXT. CAR - MOVING - NIGHT", "I/E", "CAR", "MOVING", "NIGHT"},
+		{"I/E HOUSE - CONTINUOUS", "I/E", "HOUSE", "", "CONTINUOUS"},
+		{"EST. CITY SKYLINE", "EXT", "CITY SKYLINE", "", ""},
+		{"INT. HOUSE - BEDROOM", "INT", "HOUSE", "BEDROOM", ""},
+		{".FLASHBACK - MOMENTS LATER", "", "FLASHBACK", "", "MOMENTS LATER"},
+	} {
+		element := &Element{Type: SceneHeadingType, Content: test.heading}
+		scene := ParseSceneHeading(element)
+		if scene == nil {
+			t.Errorf("%q: expected a scene", test.heading)
+			continue
+		}
+		if scene.Setting != test.setting || scene.Location != test.location || scene.SubLocation != test.subLocation || scene.TimeOfDay != test.timeOfDay {
+			t.Errorf("%q: expected %q %q %q %q, got %q %q %q %q", test.heading,
+				test.setting, test.location, test.subLocation, test.timeOfDay,
+				scene.Setting, scene.Location, scene.SubLocation, scene.TimeOfDay)
+		}
+	}
+	if scene := ParseSceneHeading(&Element{Type: SceneHeadingType, Content: "THE END."}); scene != nil {
+		t.Errorf("expected THE END. not to be a scene, got %+v", scene)
+	}
+}
+
+func TestSceneBreakdown(t *testing.T) {
+	src := []byte(`FADE IN:
+
+INT. KITCHEN - DAY
+
+BOB
+The pot is hot, it was on all night - into the morning.
+
+into the air the steam rises - slowly.
+
+ALICE
+So it is.
+
+EXT. GARDEN - NIGHT #5#
+
+BOB
+Come outside.
+
+INT. KITCHEN - CONTINUOUS
+
+ALICE
+No.
+
+BOB ^
+Please?
+
+THE END.
+`)
+	doc, err := Parse(src)
+	assertOK(t, err, "Parse(src)")
+	breakdown := doc.SceneBreakdown()
+	if len(breakdown.Scenes) != 3 {
+		t.Fatalf("expected 3 scenes, got %d", len(breakdown.Scenes))
+	}
+	expected := []string{"1:KITCHEN:BOB,ALICE", "5:GARDEN:BOB", "3:KITCHEN:ALICE,BOB"}
+	for i, scene := range breakdown.Scenes {
+		got := scene.Number + ":" + scene.Location + ":" + strings.Join(scene.Characters, ",")
+		if got != expected[i] {
+			t.Errorf("scene %d: expected %q, got %q", i, expected[i], got)
+		}
+	}
+	if breakdown.Scenes[1].Line != 13 {
+		t.Errorf("expected scene 5 on line 13, got %d", breakdown.Scenes[1].Line)
+	}
+	if breakdown.Day != 1 || breakdown.Night != 1 || breakdown.Other != 1 {
+		t.Errorf("expected 1 day, 1 night and 1 other scene, got %d, %d, %d", breakdown.Day, breakdown.Night, breakdown.Other)
+	}
+	if len(breakdown.Locations) != 2 || breakdown.Locations[0].Location != "KITCHEN" || breakdown.Locations[0].Scenes != 2 {
+		t.Errorf("expected the KITCHEN in 2 scenes, got %+v", breakdown.Locations[0])
+	}
+}
@@ -0,0 +1,82 @@
+// Package fountain is a Golang package supporting Fountain screenplay markup.
+//
+// source.go writes a screenplay back as it was written (a lossless round
+// trip) keeping the whitespace, forced markers, notes and boneyard.
+package fountain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// joinSource joins the source of the elements with their line endings
+func joinSource(elements []*Element) string {
+	src := []string{}
+	for i, element := range elements {
+		src = append(src, element.Source)
+		if i < len(elements)-1 {
+			src = append(src, element.ending)
+		}
+	}
+	return strings.Join(src, "")
+}
+
+// sourceSceneNumber writes the scene number of a heading back into its
+// source if it has changed (e.g. by NumberScenes).
+func sourceSceneNumber(element *Element) string {
+	src := element.Source
+	number := ""
+	m := reSceneNo.FindStringSubmatchIndex(src)
+	if m != nil {
+		number = src[m[2]:m[3]]
+	}
+	if number == element.SceneNumber {
+		return src
+	}
+	if m != nil {
+		src = src[0:m[0]]
+	}
+	if element.SceneNumber == "" {
+		return src
+	}
+	return fmt.Sprintf("%s #%s#", src, element.SceneNumber)
+}
+
+// RenderSource returns the screenplay as it was written, keeping the
+// whitespace, line endings (even mixed "\n" and "\r\n"), forced markers,
+// notes, sections and boneyard so parsing and writing a screenplay gives
+// back the same text. Only scene numbers changed since parsing (e.g. by
+// NumberScenes) are written. Elements without a source (e.g. read from
+// Final Draft or JSON, or added by a program) are written with
+// RenderString and opt, using the line ending of the first line.
+func (doc *Fountain) RenderSource(opt *Options) string {
+	opt = options(opt)
+	newline := doc.newline
+	if newline == "" {
+		newline = "\n"
+	}
+	elements := append([]*Element{}, doc.TitlePage...)
+	elements = append(elements, doc.Elements...)
+	src := []string{}
+	for i, element := range elements {
+		var s string
+		switch {
+		case element.Type == SceneHeadingType && element.Source != "":
+			s = sourceSceneNumber(element)
+		case element.Source == "":
+			// NOTE: an empty source is also a blank line, those print
+			// as white space when pretty printed.
+			if pretty := element.RenderString(opt); strings.TrimSpace(pretty) != "" {
+				s = strings.ReplaceAll(pretty, "\n", newline)
+			}
+		default:
+			s = element.Source
+		}
+		ending := element.ending
+		if ending == "" && (i < len(elements)-1 || !doc.noFinalNewline) {
+			ending = newline
+		}
+		src = append(src, s+ending)
+	}
+	return strings.Join(src, "")
+}
@@ -0,0 +1,73 @@
+package fountain
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"testing"
+)
+
+func TestRenderSource(t *testing.T) {
+	// Parse followed by RenderSource gives back the source
+	for i := 1; i <= 7; i++ {
+		fname := path.Join("testdata", fmt.Sprintf("sample-%02d.fountain", i))
+		src, err := os.ReadFile(fname)
+		assertOK(t, err, "ReadFile("+fname+")")
+		doc, err := Parse(src)
+		assertOK(t, err, "Parse("+fname+")")
+		if got := doc.RenderSource(nil); got != string(src) {
+			t.Errorf("%s: expected the source back, got\n%s", fname, got)
+		}
+	}
+
+	for _, src := range []string{
+		"",
+		"Title: Test\r\nAuthor: Me\r\n\r\nINT. LAB - DAY\r\n\r\n!CUT TO:\r\n",
+		"int. lab - day   \n\n\n\n@McCLANE\n  (beat)\nYippee.   \n\n> FADE OUT.",
+		"BRICK\nScrew retirement.\n\n\n\nSTEEL ^\nScrew retirement.\n",
+		"/* boneyard\n\ncut */\n\n[[note\n\nstill the note]]\n\n# Act\n\n= synopsis\n",
+		// Mixed line endings, e.g. a file edited on two systems
+		"Title: Test\r\nAuthor: Me\n\r\nINT. LAB - DAY\n\r\nBOB\r\nHi.\nThere.\r\n\n/* cut\r\n\nthis */\r\n",
+		"BRICK\r\nScrew retirement.\n\r\nSTEEL ^\nScrew retirement.\r\n\nThe end.",
+	} {
+		doc, err := Parse([]byte(src))
+		assertOK(t, err, "Parse(src)")
+		if got := doc.RenderSource(nil); got != src {
+			t.Errorf("expected %q, got %q", src, got)
+		}
+	}
+
+	// Only the scene numbers are rewritten after numbering the scenes
+	src := "EXT. GARDEN - NIGHT   #5#\n\nint. kitchen - day\n\n[[ kept ]]\n\n.FLASHBACK #12#\n"
+	doc, err := Parse([]byte(src))
+	assertOK(t, err, "Parse(src)")
+	doc.NumberScenes(true)
+	expected := strings.Join([]string{
+		"EXT. GARDEN - NIGHT #1#",
+		"",
+		"int. kitchen - day #2#",
+		"",
+		"[[ kept ]]",
+		"",
+		".FLASHBACK #3#",
+		"",
+	}, "\n")
+	if got := doc.RenderSource(nil); got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+
+	// Elements without a source are pretty printed
+	doc.Elements = append(doc.Elements, &Element{Type: ActionType, Content: "The lights go out."})
+	if got := doc.RenderSource(nil); !strings.HasSuffix(got, "#3#\nThe lights go out.\n") {
+		t.Errorf("expected the new action written, got %q", got)
+	}
+
+	// with the line ending of the first line
+	doc, err = Parse([]byte("INT. LAB - DAY\r\n\nBob waits."))
+	assertOK(t, err, "Parse(src)")
+	doc.Elements = append(doc.Elements, &Element{Type: ActionType, Content: "The lights go out."})
+	if got := doc.RenderSource(nil); got != "INT. LAB - DAY\r\n\nBob waits.\r\nThe lights go out." {
+		t.Errorf("expected the new action written, got %q", got)
+	}
+}
@@ -0,0 +1,246 @@
+// Package fountain is a Golang package supporting Fountain screenplay markup.
+//
+// titlepage.go gathers the title page elements into the standard keys
+// (title, credit, author, etc.) with their values cleaned up.
+package fountain
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// TitlePageField is a title page key and its cleaned value
+type TitlePageField struct {
+	Key   string `json:"key" yaml:"key"`
+	Value string `json:"value" yaml:"value"`
+}
+
+// TitlePage holds the standard title page keys and any other keys in
+// the order they were written. Values spanning several lines are
+// trimmed and joined with a newline. The title page ends at the first
+// blank line, so a value has none (those in elements built by hand are
+// dropped). The values keep their emphasis markup (e.g. "_Big Fish_").
+type TitlePage struct {
+	Title     string            `json:"title,omitempty" yaml:"title,omitempty"`
+	Credit    string            `json:"credit,omitempty" yaml:"credit,omitempty"`
+	Author    string            `json:"author,omitempty" yaml:"author,omitempty"`
+	Source    string            `json:"source,omitempty" yaml:"source,omitempty"`
+	DraftDate string            `json:"draft_date,omitempty" yaml:"draft_date,omitempty"`
+	Contact   string            `json:"contact,omitempty" yaml:"contact,omitempty"`
+	Copyright string            `json:"copyright,omitempty" yaml:"copyright,omitempty"`
+	Notes     string            `json:"notes,omitempty" yaml:"notes,omitempty"`
+	Extra     []*TitlePageField `json:"extra,omitempty" yaml:"extra,omitempty"`
+}
+
+// titlePageKey returns the lower case key of a title page element with
+// the alternate spellings ("Authors", "Date") mapped to the standard key
+func titlePageKey(name string) string {
+	key := strings.ToLower(strings.TrimSpace(name))
+	switch key {
+	case "authors":
+		return "author"
+	case "date":
+		return "draft date"
+	}
+	return key
+}
+
+// titlePageLines returns the trimmed, non-blank lines of a title page
+// value
+func titlePageLines(content string) []string {
+	lines := []string{}
+	for _, line := range strings.Split(content, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// appendValue joins a repeated key's value to the one already found
+func appendValue(value string, more string) string {
+	if value == "" || more == "" {
+		return value + more
+	}
+	return value + "\n" + more
+}
+
+// ParseTitlePage gathers the title page elements into a TitlePage. Keys
+// are case insensitive, "Authors" is read as Author and "Date" as
+// DraftDate. A key used more than once has its values joined with a
+// newline.
+func ParseTitlePage(titlePage []*Element) *TitlePage {
+	tp := new(TitlePage)
+	for _, element := range titlePage {
+		if element.Type != TitlePageType {
+			continue
+		}
+		value := strings.Join(titlePageLines(element.text()), "\n")
+		if field := tp.field(titlePageKey(element.Name)); field != nil {
+			*field = appendValue(*field, value)
+			continue
+		}
+		key := strings.TrimSpace(element.Name)
+		found := false
+		for _, extra := range tp.Extra {
+			if strings.EqualFold(extra.Key, key) {
+				extra.Value = appendValue(extra.Value, value)
+				found = true
+				break
+			}
+		}
+		if !found {
+			tp.Extra = append(tp.Extra, &TitlePageField{Key: key, Value: value})
+		}
+	}
+	return tp
+}
+
+// field returns the standard field for a lower case key, nil if the
+// key is not a standard one
+func (tp *TitlePage) field(key string) *string {
+	switch key {
+	case "title":
+		return &tp.Title
+	case "credit":
+		return &tp.Credit
+	case "author":
+		return &tp.Author
+	case "source":
+		return &tp.Source
+	case "draft date":
+		return &tp.DraftDate
+	case "contact":
+		return &tp.Contact
+	case "copyright":
+		return &tp.Copyright
+	case "notes":
+		return &tp.Notes
+	}
+	return nil
+}
+
+// Get returns the value of a key (case insensitive), an empty string if
+// the title page doesn't have it.
+func (tp *TitlePage) Get(key string) string {
+	if field := tp.field(titlePageKey(key)); field != nil {
+		return *field
+	}
+	for _, extra := range tp.Extra {
+		if strings.EqualFold(extra.Key, strings.TrimSpace(key)) {
+			return extra.Value
+		}
+	}
+	return ""
+}
+
+// Fields returns the keys with a value, the standard keys first and then
+// the other keys in the order they were written.
+func (tp *TitlePage) Fields() []*TitlePageField {
+	fields := []*TitlePageField{}
+	for _, field := range []*TitlePageField{
+		{"Title", tp.Title},
+		{"Credit", tp.Credit},
+		{"Author", tp.Author},
+		{"Source", tp.Source},
+		{"Draft date", tp.DraftDate},
+		{"Contact", tp.Contact},
+		{"Copyright", tp.Copyright},
+		{"Notes", tp.Notes},
+	} {
+		if field.Value != "" {
+			fields = append(fields, field)
+		}
+	}
+	for _, extra := range tp.Extra {
+		if extra.Value != "" {
+			fields = append(fields, extra)
+		}
+	}
+	return fields
+}
+
+// titlePageHTML renders a title page value with its lines separated by
+// line breaks and the emphasis as HTML
+func titlePageHTML(class string, value string) string {
+	lines := []string{}
+	for _, line := range strings.Split(value, "\n") {
+		lines = append(lines, spansToHTML(ParseEmphasis(line), nil))
+	}
+	return createElement("div", []string{class}, strings.Join(lines, "<br>\n"))
+}
+
+// titlePageClass returns the class for a title page key, e.g. "Draft
+// date" has the class "draft-date"
+func titlePageClass(key string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return '-'
+	}, strings.TrimSpace(key))
+}
+
+// RenderHTML lays out the title page in the standard positions, title,
+// credit, author and source centered, contact, copyright and the other
+// keys bottom left and draft date and notes bottom right. The other
+// keys have the class "general-text" and one named after the key, e.g.
+// "revision" for "Revision".
+func (tp *TitlePage) RenderHTML() string {
+	out := []string{}
+	block := func(class string, fields []*TitlePageField) {
+		parts := []string{}
+		for _, field := range fields {
+			if field.Value == "" {
+				continue
+			}
+			class := titlePageClass(field.Key)
+			if tp.field(titlePageKey(field.Key)) == nil {
+				class = "general-text " + class
+			}
+			parts = append(parts, titlePageHTML(class, field.Value))
+		}
+		if len(parts) > 0 {
+			out = append(out, fmt.Sprintf("<div class=%q>\n%s</div>\n", class, strings.Join(parts, "")))
+		}
+	}
+	block("title-page-center", []*TitlePageField{
+		{"Title", tp.Title},
+		{"Credit", tp.Credit},
+		{"Author", tp.Author},
+		{"Source", tp.Source},
+	})
+	left := []*TitlePageField{
+		{"Contact", tp.Contact},
+		{"Copyright", tp.Copyright},
+	}
+	block("title-page-left", append(left, tp.Extra...))
+	block("title-page-right", []*TitlePageField{
+		{"Draft date", tp.DraftDate},
+		{"Notes", tp.Notes},
+	})
+	return strings.Join(out, "")
+}
+
+// String returns the title page in Fountain markup, a value spanning
+// several lines is written indented below its key.
+func (tp *TitlePage) String() string {
+	src := []string{}
+	for _, field := range tp.Fields() {
+		src = append(src, titlePageString(field.Key, field.Value))
+	}
+	return strings.Join(src, "\n")
+}
+
+// titlePageString writes a title page key and its value
+func titlePageString(key string, value string) string {
+	lines := titlePageLines(value)
+	switch len(lines) {
+	case 0:
+		return key + ":"
+	case 1:
+		return key + ": " + lines[0]
+	}
+	return key + ":\n\t" + strings.Join(lines, "\n\t")
+}
@@ -0,0 +1,199 @@
+package fountain
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseTitlePage(t *testing.T) {
+	src := `Title:
+    _**BRICK & STEEL**_
+    _**FULL RETIRED**_
+Credit: Written by
+authors: Stu Maschwitz
+Source: Story by KTM
+Date: 1/20/2012
+Contact:
+    Next Level Productions
+    1588 Mission Dr.: Suite 2
+    Solvang, CA 93463
+Revision: Blue
+Author: Alex
+Revision:   Pink
+
+EXT. BRICK'S PATIO - DAY
+
+Hi.
+`
+	doc, err := Parse([]byte(src))
+	assertOK(t, err, "Parse(src)")
+	tp := ParseTitlePage(doc.TitlePage)
+	expected := &TitlePage{
+		Title:     "_**BRICK & STEEL**_\n_**FULL RETIRED**_",
+		Credit:    "Written by",
+		Author:    "Stu Maschwitz\nAlex",
+		Source:    "Story by KTM",
+		DraftDate: "1/20/2012",
+		Contact:   "Next Level Productions\n1588 Mission Dr.: Suite 2\nSolvang, CA 93463",
+	}
+	for _, key := range []string{"Title", "Credit", "Author", "Source", "Draft date", "Contact", "Copyright", "Notes"} {
+		if got, want := tp.Get(key), expected.Get(key); got != want {
+			t.Errorf("expected %s %q, got %q", key, want, got)
+		}
+	}
+	if len(tp.Extra) != 1 || tp.Extra[0].Key != "Revision" || tp.Extra[0].Value != "Blue\nPink" {
+		t.Errorf("expected the extra key Revision: Blue, Pink, got %+v", tp.Extra)
+	}
+	if got := tp.Get("authors"); got != tp.Author {
+		t.Errorf("expected authors to read Author, got %q", got)
+	}
+	if got := tp.Get("REVISION"); got != "Blue\nPink" {
+		t.Errorf("expected the revision, got %q", got)
+	}
+
+	// String writes the cleaned values in standard order
+	expectedSrc := `Title:
+	_**BRICK & STEEL**_
+	_**FULL RETIRED**_
+Credit: Written by
+Author:
+	Stu Maschwitz
+	Alex
+Source: Story by KTM
+Draft date: 1/20/2012
+Contact:
+	Next Level Productions
+	1588 Mission Dr.: Suite 2
+	Solvang, CA 93463
+Revision:
+	Blue
+	Pink`
+	if got := tp.String(); got != expectedSrc {
+		t.Errorf("expected\n%s\ngot\n%s", expectedSrc, got)
+	}
+	reparsed, err := Parse([]byte(tp.String() + "\n\nINT. HOUSE - DAY\n"))
+	assertOK(t, err, "Parse(tp.String())")
+	if got := ParseTitlePage(reparsed.TitlePage).String(); got != expectedSrc {
+		t.Errorf("expected the same title page after parsing, got\n%s", got)
+	}
+
+	// The elements are printed cleaned up, a blank line ends the title page
+	if got := doc.String(); !strings.HasPrefix(got, "Title:\n\t_**BRICK & STEEL**_\n\t_**FULL RETIRED**_\nCredit: Written by\n") ||
+		!strings.Contains(got, "\nRevision: Pink\n\nEXT. BRICK'S PATIO - DAY") {
+		t.Errorf("expected the cleaned title page, got\n%s", got)
+	}
+
+	// ToHTML puts the keys in their standard positions
+	html := doc.ToHTML()
+	for _, expected := range []string{
+		`<div class="title-page-center">
+<div class="title"><u><strong>BRICK & STEEL</strong></u><br>
+<u><strong>FULL RETIRED</strong></u></div>
+<div class="credit">Written by</div>
+<div class="author">Stu Maschwitz<br>
+Alex</div>
+<div class="source">Story by KTM</div>
+</div>`,
+		`<div class="title-page-left">
+<div class="contact">Next Level Productions<br>
+1588 Mission Dr.: Suite 2<br>
+Solvang, CA 93463</div>
+<div class="general-text revision">Blue<br>
+Pink</div>
+</div>`,
+		`<div class="title-page-right">
+<div class="draft-date">1/20/2012</div>
+</div>`,
+	} {
+		if !strings.Contains(html, expected) {
+			t.Errorf("expected\n%s\nin\n%s", expected, html)
+		}
+	}
+
+	// Notes are bottom right in HTML and in the FDX and FadeIn layout
+	titlePage := []*Element{
+		{Type: TitlePageType, Name: "Title", Content: "Test"},
+		{Type: TitlePageType, Name: "Notes", Content: "Second draft"},
+		{Type: TitlePageType, Name: "Draft date", Content: "1/20/2012"},
+	}
+	if got := ParseTitlePage(titlePage).RenderHTML(); !strings.Contains(got, `<div class="title-page-right">
+<div class="draft-date">1/20/2012</div>
+<div class="notes">Second draft</div>
+</div>`) {
+		t.Errorf("expected the notes bottom right, got\n%s", got)
+	}
+	align := ""
+	for _, paragraph := range titlePageLayout(titlePage) {
+		if paragraph.text == "Second draft" {
+			align = paragraph.align
+		}
+	}
+	if align != "right" {
+		t.Errorf("expected the notes on the right, got %q", align)
+	}
+
+	if got := ParseTitlePage(nil).RenderHTML(); got != "" {
+		t.Errorf("expected no HTML for an empty title page, got %q", got)
+	}
+}
+
+func TestTitlePageDetection(t *testing.T) {
+	// Scripts without a title page
+	for _, src := range []string{
+		"INT. HOUSE - DAY\n\nBob enters.\n",
+		"Bob enters.\n\nINT. HOUSE - DAY\n",
+		"BOB\nHi: there.\n",
+		"FADE IN:\n\nINT. HOUSE - DAY\n",
+		"!Note: this is action\n",
+		"\nTitle: not a title page\n",
+		"  Title: indented\n",
+		"",
+	} {
+		doc, err := Parse([]byte(src))
+		assertOK(t, err, "Parse(src)")
+		if len(doc.TitlePage) != 0 {
+			t.Errorf("expected no title page for %q, got %s", src, doc.TitlePage[0].Name)
+		}
+		if got := doc.RenderSource(nil); got != src {
+			t.Errorf("expected %q back, got %q", src, got)
+		}
+	}
+	doc, err := Parse([]byte("BOB\nHi: there.\n"))
+	assertOK(t, err, "Parse(src)")
+	if len(doc.Elements) != 2 || doc.Elements[0].Type != CharacterType || doc.Elements[1].Type != DialogueType {
+		t.Errorf("expected a character and dialogue, got %+v", doc.Elements)
+	}
+
+	// The first blank line ends the title page
+	src := "Title: Test\nAuthor: Me\n\nNotes: not on the title page\n\n\nINT. HOUSE - DAY\n"
+	doc, err = Parse([]byte(src))
+	assertOK(t, err, "Parse(src)")
+	if len(doc.TitlePage) != 2 || doc.TitlePage[1].Name != "Author" || doc.TitlePage[1].Content != " Me" {
+		t.Fatalf("expected the title and author, got %d elements", len(doc.TitlePage))
+	}
+	expected := []int{EmptyType, ActionType, EmptyType, SceneHeadingType}
+	if len(doc.Elements) != len(expected) {
+		t.Fatalf("expected %d elements, got %d", len(expected), len(doc.Elements))
+	}
+	for i, element := range doc.Elements {
+		if element.Type != expected[i] {
+			t.Errorf("(%d) expected %q, got %q for %q", i, typeName(expected[i]), element.TypeName(), element.Content)
+		}
+	}
+	if got := doc.RenderSource(nil); got != src {
+		t.Errorf("expected %q back, got %q", src, got)
+	}
+	if got := doc.String(); !strings.HasPrefix(got, "Title: Test\nAuthor: Me\n\nNotes: not on the title page\n") {
+		t.Errorf("expected a blank line after the title page, got %q", got)
+	}
+
+	// A title page followed directly by a scene heading
+	doc, err = Parse([]byte("Title: Test\nINT. HOUSE - DAY\n"))
+	assertOK(t, err, "Parse(src)")
+	if len(doc.TitlePage) != 1 || len(doc.Elements) == 0 || doc.Elements[0].Type != SceneHeadingType {
+		t.Errorf("expected a title and a scene heading, got %d and %d elements", len(doc.TitlePage), len(doc.Elements))
+	}
+	if got := doc.String(); got != "Title: Test\n\nINT. HOUSE - DAY" {
+		t.Errorf("expected a blank line added after the title page, got %q", got)
+	}
+}